@@ -0,0 +1,845 @@
+package tda
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// AlphaComplex supports calculation of the persistent homology of the
+// alpha filtration of a point set in two or three dimensions.  The
+// alpha complex at scale r is the subcomplex of the Delaunay
+// triangulation consisting of the simplices that have an empty
+// circumsphere of radius at most r.  It has the same homotopy type as
+// the union of balls of radius r centered at the points, but is much
+// smaller than the Rips complex at the same scale.
+type AlphaComplex struct {
+
+	// The dimension of the space, 2 or 3
+	d int
+
+	// The n x d matrix of point coordinates, in row-major order
+	x []float64
+
+	// The simplices of the Delaunay triangulation that are not
+	// flat, in filtration order, each given as an increasing sequence of vertices
+	simplices [][]int
+
+	// The filtration value of each simplex, which is the radius
+	// at which it enters the alpha complex
+	values []float64
+
+	// The persistence pairs, including classes that never die
+	pairs []PersistencePair
+}
+
+// NewAlphaComplex calculates the persistent homology of the alpha
+// filtration of a set of points in d = 2 or d = 3 dimensions.  The
+// n x d matrix x holds the coordinates of the points in row-major
+// order.  The filtration values are radii, so a pair of points is
+// joined at half of the distance between them.  The Delaunay
+// triangulation is constructed with the Bowyer-Watson algorithm, and
+// the points should be distinct.  Points in degenerate position (for
+// example d+2 points on a common sphere) have several Delaunay
+// triangulations, and one of them is chosen arbitrarily.
+func NewAlphaComplex(x []float64, d int) *AlphaComplex {
+
+	if d != 2 && d != 3 {
+		panic("d must be 2 or 3")
+	}
+
+	if len(x)%d != 0 {
+		panic("d is not compatible with x")
+	}
+
+	ac := &AlphaComplex{
+		d: d,
+		x: x,
+	}
+
+	ac.build()
+
+	// The complex is contractible, so only one component never
+	// dies.
+	var end float64
+	if len(ac.values) > 0 {
+		end = ac.values[len(ac.values)-1]
+	}
+	ac.pairs = filtrationPairs(ac.simplices, ac.values, d-1, end)
+
+	return ac
+}
+
+// circumsphere returns the center and squared radius of the smallest
+// sphere passing through the vertices of the simplex s, whose vertex
+// coordinates are given by the rows of the matrix x with d columns.
+// If the vertices do not lie on a common sphere, the squared radius
+// is infinite.
+func circumsphere(x []float64, d int, s []int) ([]float64, float64) {
+
+	p0 := x[s[0]*d : s[0]*d+d]
+	k := len(s) - 1
+
+	// The center is p0 + sum_i lam_i v_i with v_i = p_i - p0,
+	// where 2 * G * lam = b, G is the Gram matrix of the v_i and
+	// b holds the squared lengths of the v_i.
+	v := make([]float64, k*d)
+	for i := 0; i < k; i++ {
+		for j := 0; j < d; j++ {
+			v[i*d+j] = x[s[i+1]*d+j] - p0[j]
+		}
+	}
+	a := make([]float64, k*(k+1))
+	for i := 0; i < k; i++ {
+		for j := 0; j < k; j++ {
+			var g float64
+			for l := 0; l < d; l++ {
+				g += v[i*d+l] * v[j*d+l]
+			}
+			a[i*(k+1)+j] = 2 * g
+			if i == j {
+				a[i*(k+1)+k] = g
+			}
+		}
+	}
+
+	lam, ok := solve(a, k)
+	c := make([]float64, d)
+	copy(c, p0)
+	if !ok {
+		// The vertices are affinely dependent.  If they lie on
+		// the sphere through a subset of the vertices, then
+		// that is the smallest sphere.
+		if k > 1 {
+			sub := make([]int, 0, k)
+			for i := range s {
+				sub = append(sub[0:0], s[0:i]...)
+				sub = append(sub, s[i+1:]...)
+				c1, r2 := circumsphere(x, d, sub)
+				if !math.IsInf(r2, 1) && math.Abs(sqdist(x[s[i]*d:s[i]*d+d], c1)-r2) <= 1e-10*r2 {
+					return c1, r2
+				}
+			}
+		}
+		return c, math.Inf(1)
+	}
+
+	for i := 0; i < k; i++ {
+		for j := 0; j < d; j++ {
+			c[j] += lam[i] * v[i*d+j]
+		}
+	}
+
+	var r2 float64
+	for j := 0; j < d; j++ {
+		u := c[j] - p0[j]
+		r2 += u * u
+	}
+
+	return c, r2
+}
+
+// solve solves a k x k linear system using Gaussian elimination with
+// partial pivoting.  The matrix a holds the augmented k x (k+1)
+// system in row-major order, and is overwritten.  The second return
+// value is false if the system is singular.
+func solve(a []float64, k int) ([]float64, bool) {
+
+	w := k + 1
+	var scale float64
+	for _, v := range a {
+		scale = math.Max(scale, math.Abs(v))
+	}
+
+	for c := 0; c < k; c++ {
+		p := c
+		for r := c + 1; r < k; r++ {
+			if math.Abs(a[r*w+c]) > math.Abs(a[p*w+c]) {
+				p = r
+			}
+		}
+		if math.Abs(a[p*w+c]) <= 1e-12*scale {
+			return nil, false
+		}
+		for j := 0; j < w; j++ {
+			a[c*w+j], a[p*w+j] = a[p*w+j], a[c*w+j]
+		}
+		for r := c + 1; r < k; r++ {
+			f := a[r*w+c] / a[c*w+c]
+			for j := c; j < w; j++ {
+				a[r*w+j] -= f * a[c*w+j]
+			}
+		}
+	}
+
+	z := make([]float64, k)
+	for r := k - 1; r >= 0; r-- {
+		s := a[r*w+k]
+		for j := r + 1; j < k; j++ {
+			s -= a[r*w+j] * z[j]
+		}
+		z[r] = s / a[r*w+r]
+	}
+
+	return z, true
+}
+
+// orient returns the signed volume (up to a constant) of the simplex
+// formed by the d vertices in f and the point q, whose coordinates
+// are in the rows of the matrix x with d columns.
+func orient(x []float64, d int, f [3]int, q []float64) float64 {
+
+	var m [3][3]float64
+	p0 := x[f[0]*d : f[0]*d+d]
+	for i := 0; i < d; i++ {
+		r := q
+		if i < d-1 {
+			r = x[f[i+1]*d : f[i+1]*d+d]
+		}
+		for j := 0; j < d; j++ {
+			m[i][j] = r[j] - p0[j]
+		}
+	}
+
+	if d == 2 {
+		return m[0][0]*m[1][1] - m[0][1]*m[1][0]
+	}
+
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// insphere returns a value that is positive if the point q is inside
+// the circumsphere of the full-dimensional simplex with vertices s,
+// whose coordinates are in the rows of the matrix x with d columns,
+// and negative if it is outside.  The value is a determinant in the
+// coordinates relative to q, which remains accurate for nearly flat
+// simplices, unlike a comparison with the center and radius.
+func insphere(x []float64, d int, s []int, q []float64) float64 {
+
+	var m [4][4]float64
+	for i := 0; i <= d; i++ {
+		p := x[s[i]*d : s[i]*d+d]
+		for j := 0; j < d; j++ {
+			m[i][j] = p[j] - q[j]
+			m[i][d] += m[i][j] * m[i][j]
+		}
+	}
+
+	det3 := func(r0, r1, r2 [4]float64) float64 {
+		return r0[0]*(r1[1]*r2[2]-r1[2]*r2[1]) -
+			r0[1]*(r1[0]*r2[2]-r1[2]*r2[0]) +
+			r0[2]*(r1[0]*r2[1]-r1[1]*r2[0])
+	}
+
+	f := [3]int{s[0], s[1], -1}
+	if d == 2 {
+		return det3(m[0], m[1], m[2]) * orient(x, d, f, x[s[2]*d:s[2]*d+d])
+	}
+
+	// Expand along the column of squared lengths.
+	var v float64
+	for i := 0; i < 4; i++ {
+		var r [3][4]float64
+		k := 0
+		for j := 0; j < 4; j++ {
+			if j != i {
+				r[k] = m[j]
+				k++
+			}
+		}
+		u := m[i][3] * det3(r[0], r[1], r[2])
+		if i%2 == 0 {
+			v += u
+		} else {
+			v -= u
+		}
+	}
+	f[2] = s[2]
+	return v * orient(x, d, f, x[s[3]*d:s[3]*d+d])
+}
+
+// sqdist returns the squared distance between points p and q.
+func sqdist(p, q []float64) float64 {
+	var s float64
+	for j := range p {
+		u := p[j] - q[j]
+		s += u * u
+	}
+	return s
+}
+
+// bwSimplex is a full-dimensional simplex in the Bowyer-Watson
+// algorithm.  A simplex that includes the vertex at infinity joins a
+// face of the convex hull to that vertex, and holds the circumsphere
+// of the face.
+type bwSimplex struct {
+	v [4]int
+
+	// The simplex sharing the face opposite to each vertex
+	nb [4]int
+
+	center []float64
+	r2     float64
+
+	// Stamps indicating that the simplex is in the current cavity,
+	// or has been excluded from it
+	mark, excl int
+
+	dead bool
+}
+
+// delaunay returns the simplices of the Delaunay triangulation of the
+// n points in the rows of x, in all dimensions from 0 to d, using the
+// Bowyer-Watson algorithm.  The points are placed one at a time, and
+// the simplices whose circumspheres contain each new point are
+// replaced by simplices joining the point to the boundary of the
+// cavity that they form.  The faces of the convex hull are joined to a
+// symbolic vertex at infinity, so that no enclosing simplex is needed,
+// and a point outside of the hull is in conflict with the hull faces
+// that are visible from it.  The simplex containing each new point is
+// found by walking from the most recently created simplex.
+func delaunay(x []float64, d int) [][]int {
+
+	n := len(x) / d
+	if n == 0 {
+		return nil
+	}
+
+	// Up to d points in general position form a single simplex.
+	if n <= d {
+		var result [][]int
+		for mask := 1; mask < 1<<uint(n); mask++ {
+			var f []int
+			for j := 0; j < n; j++ {
+				if mask&(1<<uint(j)) != 0 {
+					f = append(f, j)
+				}
+			}
+			result = append(result, f)
+		}
+		return result
+	}
+
+	// The half-width of the bounding box
+	var rad float64
+	for j := 0; j < d; j++ {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for i := 0; i < n; i++ {
+			lo = math.Min(lo, x[i*d+j])
+			hi = math.Max(hi, x[i*d+j])
+		}
+		rad = math.Max(rad, (hi-lo)/2)
+	}
+	if rad == 0 {
+		rad = 1
+	}
+
+	// The points are perturbed by a tiny amount to break ties
+	// among points lying on a common sphere, which would otherwise
+	// produce overlapping simplices.
+	rng := rand.New(rand.NewSource(1))
+	xa := make([]float64, len(x))
+	for i := range x {
+		xa[i] = x[i] + 1e-9*rad*(rng.Float64()-0.5)
+	}
+	pt := func(i int) []float64 {
+		return xa[i*d : i*d+d]
+	}
+
+	// The index of the vertex at infinity
+	inf := n
+
+	var simp []bwSimplex
+	var free []int
+	alloc := func(v [4]int) int {
+		s := bwSimplex{v: v, nb: [4]int{-1, -1, -1, -1}}
+		f := make([]int, 0, d+1)
+		for _, u := range v[0 : d+1] {
+			if u != inf {
+				f = append(f, u)
+			}
+		}
+		if len(f) == d {
+			s.center, s.r2 = circumsphere(xa, d, f)
+		}
+		if m := len(free); m > 0 {
+			k := free[m-1]
+			free = free[0 : m-1]
+			simp[k] = s
+			return k
+		}
+		simp = append(simp, s)
+		return len(simp) - 1
+	}
+
+	// ghost returns the position of the vertex at infinity in
+	// simplex k, or -1.
+	ghost := func(k int) int {
+		for i := 0; i <= d; i++ {
+			if simp[k].v[i] == inf {
+				return i
+			}
+		}
+		return -1
+	}
+
+	// face returns the face of simplex k opposite to vertex i.
+	face := func(k, i int) [3]int {
+		f := [3]int{-1, -1, -1}
+		m := 0
+		for j := 0; j <= d; j++ {
+			if j != i {
+				f[m] = simp[k].v[j]
+				m++
+			}
+		}
+		return f
+	}
+
+	// side returns a value that is positive if the point q is on the
+	// same side of the face of simplex k opposite to vertex i as
+	// vertex i, and negative if it is on the other side.  The vertex
+	// at infinity is on the side of a hull face away from the
+	// points, and faces including the vertex at infinity are not
+	// tested.
+	side := func(k, i int, q []float64) float64 {
+		f := face(k, i)
+		if simp[k].v[i] == inf {
+			t := simp[k].nb[i]
+			for m := 0; m <= d; m++ {
+				if simp[t].nb[m] == k {
+					w := simp[t].v[m]
+					return -orient(xa, d, f, q) * orient(xa, d, f, pt(w))
+				}
+			}
+		}
+		if ghost(k) >= 0 {
+			return 1
+		}
+		return orient(xa, d, f, q) * orient(xa, d, f, pt(simp[k].v[i]))
+	}
+
+	// conflict returns true if the point q is in the circumsphere
+	// of simplex k.  The circumsphere of a hull simplex is the
+	// open half-space beyond the hull face, along with the
+	// circumsphere of the face within its plane.
+	conflict := func(k int, q []float64) bool {
+		if i := ghost(k); i >= 0 {
+			if o := side(k, i, q); o != 0 {
+				return o > 0
+			}
+			return sqdist(q, simp[k].center) < simp[k].r2
+		}
+		return insphere(xa, d, simp[k].v[0:d+1], q) > 0
+	}
+
+	// locate walks from simplex k toward the point q, and returns
+	// the finite simplex containing q, or the hull simplex through
+	// which q is reached if it is outside of the hull.  The face
+	// to cross is chosen at random, which guarantees that the walk
+	// terminates.  If rounding prevents the walk from finishing, -1
+	// is returned.
+	locate := func(k int, q []float64) int {
+		for steps := 0; steps <= len(simp); steps++ {
+			if ghost(k) >= 0 {
+				return k
+			}
+			next := -1
+			r := rng.Intn(d + 1)
+			for m := 0; m <= d; m++ {
+				i := (r + m) % (d + 1)
+				if side(k, i, q) < 0 {
+					next = simp[k].nb[i]
+					break
+				}
+			}
+			if next == -1 {
+				return k
+			}
+			k = next
+		}
+		return -1
+	}
+
+	// The initial simplex is spanned by points that are far apart,
+	// so that it is well-shaped.  The vertex at position m is the
+	// point with the greatest score among those not yet chosen.
+	var init [4]int
+	best := func(m int, score func(q []float64) float64) int {
+		j, s := -1, -1.0
+		for i := 0; i < n; i++ {
+			if containsInt(init[0:m], i) {
+				continue
+			}
+			if v := math.Abs(score(pt(i))); v > s {
+				j, s = i, v
+			}
+		}
+		return j
+	}
+	init[1] = best(1, func(q []float64) float64 { return sqdist(q, pt(0)) })
+	if d == 2 {
+		init[2] = best(2, func(q []float64) float64 { return orient(xa, d, [3]int{0, init[1], -1}, q) })
+	} else {
+		init[2] = best(2, func(q []float64) float64 {
+			a, b := pt(init[1]), pt(0)
+			var u, v [3]float64
+			for j := range u {
+				u[j] = a[j] - b[j]
+				v[j] = q[j] - b[j]
+			}
+			c0 := u[1]*v[2] - u[2]*v[1]
+			c1 := u[2]*v[0] - u[0]*v[2]
+			c2 := u[0]*v[1] - u[1]*v[0]
+			return c0*c0 + c1*c1 + c2*c2
+		})
+		init[3] = best(3, func(q []float64) float64 { return orient(xa, d, [3]int{0, init[1], init[2]}, q) })
+	}
+
+	// The initial simplex, and the hull simplices joining each of
+	// its faces to the vertex at infinity
+	k0 := alloc(init)
+	for i := 0; i <= d; i++ {
+		g := init
+		g[i] = inf
+		alloc(g)
+	}
+	for i := 0; i <= d; i++ {
+		simp[k0].nb[i] = 1 + i
+		simp[1+i].nb[i] = k0
+		for j := 0; j <= d; j++ {
+			if j != i {
+				simp[1+i].nb[j] = 1 + j
+			}
+		}
+	}
+
+	type half struct {
+		k, i int
+	}
+	ridges := make(map[[3]int]half)
+	var cav, stack []int
+	last, stamp := k0, 0
+	for p := 0; p < n; p++ {
+
+		if containsInt(init[0:d+1], p) {
+			continue
+		}
+		xp := pt(p)
+
+		// Find a simplex whose circumsphere contains the point,
+		// examining every simplex if the walk fails.
+		start := locate(last, xp)
+		if start == -1 || !conflict(start, xp) {
+			start = -1
+			for k := range simp {
+				if !simp[k].dead && conflict(k, xp) {
+					start = k
+					break
+				}
+			}
+			if start == -1 {
+				continue
+			}
+		}
+
+		// The cavity is the connected set of simplices whose
+		// circumspheres contain the point.  Due to rounding, the
+		// cavity may not be star-shaped with respect to the
+		// point, in which case the simplices with faces that are
+		// not visible from the point are excluded.
+		ins := p + 1
+		for {
+			stamp++
+			cav = cav[0:0]
+			stack = append(stack[0:0], start)
+			simp[start].mark = stamp
+			for len(stack) > 0 {
+				k := stack[len(stack)-1]
+				stack = stack[0 : len(stack)-1]
+				cav = append(cav, k)
+				for i := 0; i <= d; i++ {
+					k1 := simp[k].nb[i]
+					if simp[k1].mark != stamp && simp[k1].excl != ins && conflict(k1, xp) {
+						simp[k1].mark = stamp
+						stack = append(stack, k1)
+					}
+				}
+			}
+
+			star := true
+			for _, k := range cav {
+				if k == start {
+					continue
+				}
+				for i := 0; i <= d; i++ {
+					if simp[simp[k].nb[i]].mark != stamp && side(k, i, xp) <= 0 {
+						simp[k].excl = ins
+						star = false
+						break
+					}
+				}
+			}
+			if star {
+				break
+			}
+		}
+
+		// Replace the cavity with simplices joining the point to
+		// the faces on its boundary.  The new simplices are
+		// linked to each other through their faces that include
+		// the point.
+		for f := range ridges {
+			delete(ridges, f)
+		}
+		for _, k := range cav {
+			for i := 0; i <= d; i++ {
+				o := simp[k].nb[i]
+				if simp[o].mark == stamp {
+					continue
+				}
+				v := simp[k].v
+				v[i] = p
+				t := alloc(v)
+				simp[t].nb[i] = o
+				for m := 0; m <= d; m++ {
+					if simp[o].nb[m] == k {
+						simp[o].nb[m] = t
+					}
+				}
+				for j := 0; j <= d; j++ {
+					if j == i {
+						continue
+					}
+					f := face(t, j)
+					sort.Ints(f[0:d])
+					if h, ok := ridges[f]; ok {
+						simp[t].nb[j] = h.k
+						simp[h.k].nb[h.i] = t
+						delete(ridges, f)
+					} else {
+						ridges[f] = half{t, j}
+					}
+				}
+				if ghost(t) == -1 {
+					last = t
+				}
+			}
+		}
+		for _, k := range cav {
+			simp[k].dead = true
+			free = append(free, k)
+		}
+	}
+
+	// Collect the faces of the finite simplices.
+	seen := make(map[[4]int]bool)
+	var result [][]int
+	for k := range simp {
+		if simp[k].dead || ghost(k) >= 0 {
+			continue
+		}
+		for mask := 1; mask < 1<<uint(d+1); mask++ {
+			var f []int
+			for j := 0; j <= d; j++ {
+				if mask&(1<<uint(j)) != 0 {
+					f = append(f, simp[k].v[j])
+				}
+			}
+			sort.Ints(f)
+			key := [4]int{-1, -1, -1, -1}
+			copy(key[:], f)
+			if !seen[key] {
+				seen[key] = true
+				result = append(result, f)
+			}
+		}
+	}
+
+	// Include any points that were not placed in a simplex.
+	for p := 0; p < n; p++ {
+		if !seen[[4]int{p, -1, -1, -1}] {
+			result = append(result, []int{p})
+		}
+	}
+
+	return result
+}
+
+func (ac *AlphaComplex) build() {
+
+	d := ac.d
+	x := ac.x
+	simplices := delaunay(x, d)
+
+	// Group the simplices by dimension
+	bydim := make([][][]int, d+1)
+	for _, s := range simplices {
+		bydim[len(s)-1] = append(bydim[len(s)-1], s)
+	}
+
+	// The filtration values of the simplices in the next higher
+	// dimension, indexed by their vertices
+	upper := make(map[[4]int]float64)
+	cofaces := make(map[[4]int][][]int)
+
+	key := func(s []int) [4]int {
+		k := [4]int{-1, -1, -1, -1}
+		copy(k[:], s)
+		return k
+	}
+
+	var values []float64
+	var ordered [][]int
+	for k := d; k >= 0; k-- {
+
+		cur := make(map[[4]int]float64)
+		for _, s := range bydim[k] {
+
+			// A simplex enters the alpha complex when its
+			// smallest circumsphere does, if that sphere is
+			// empty.  Otherwise it enters with its first
+			// coface.
+			var v float64
+			gabriel := true
+			var c []float64
+			var r2 float64
+			if k > 0 {
+				c, r2 = circumsphere(x, d, s)
+				v = math.Sqrt(r2)
+			}
+			for _, t := range cofaces[key(s)] {
+				w := upper[key(t)]
+				if w < v {
+					v = w
+				}
+				if k == 0 {
+					continue
+				}
+				for _, o := range t {
+					if !containsInt(s, o) {
+						if sqdist(x[o*d:o*d+d], c) < r2*(1-1e-10) {
+							gabriel = false
+						}
+						break
+					}
+				}
+			}
+			if !gabriel {
+				v = math.Inf(1)
+				for _, t := range cofaces[key(s)] {
+					v = math.Min(v, upper[key(t)])
+				}
+			}
+
+			cur[key(s)] = v
+			ordered = append(ordered, s)
+			values = append(values, v)
+		}
+
+		// Record the cofaces of the faces in the next lower
+		// dimension.
+		cofaces = make(map[[4]int][][]int)
+		if k > 0 {
+			for _, s := range bydim[k] {
+				for i := range s {
+					f := make([]int, 0, k)
+					f = append(f, s[0:i]...)
+					f = append(f, s[i+1:]...)
+					cofaces[key(f)] = append(cofaces[key(f)], s)
+				}
+			}
+		}
+		upper = cur
+	}
+
+	// Order by filtration value, placing faces before their
+	// cofaces when the values are equal.
+	ord := make([]int, len(ordered))
+	for i := range ord {
+		ord[i] = i
+	}
+	sort.SliceStable(ord, func(i, j int) bool {
+		vi, vj := values[ord[i]], values[ord[j]]
+		if vi != vj {
+			return vi < vj
+		}
+		return len(ordered[ord[i]]) < len(ordered[ord[j]])
+	})
+
+	// Flat simplices, which arise from collinear or coplanar
+	// points on the convex hull, never enter the complex.  They
+	// have infinite values, as do the simplices whose cofaces are
+	// all flat, so they are at the end of the order.
+	ac.simplices = make([][]int, 0, len(ord))
+	ac.values = make([]float64, 0, len(ord))
+	for _, i := range ord {
+		if math.IsInf(values[i], 1) {
+			break
+		}
+		ac.simplices = append(ac.simplices, ordered[i])
+		ac.values = append(ac.values, values[i])
+	}
+}
+
+// containsInt returns true if x is an element of a.
+func containsInt(a []int, x int) bool {
+	for _, v := range a {
+		if v == x {
+			return true
+		}
+	}
+	return false
+}
+
+// Pairs returns the persistence pairs in the given dimension.  Pairs
+// in which the birth and death values are equal are omitted.
+func (ac *AlphaComplex) Pairs(dim int) []PersistencePair {
+
+	var pairs []PersistencePair
+	for _, pr := range ac.pairs {
+		if pr.Dim == dim {
+			pairs = append(pairs, pr)
+		}
+	}
+
+	return pairs
+}
+
+// BirthDeath returns the birth and death values of the homology
+// classes in the given dimension, in the same form as
+// Persistence.BirthDeath.  The one connected component that never
+// dies is given the greatest filtration value as its death value.
+func (ac *AlphaComplex) BirthDeath(dim int) ([]float64, []float64) {
+
+	var birth, death []float64
+	for _, pr := range ac.pairs {
+		if pr.Dim == dim {
+			birth = append(birth, pr.Birth)
+			death = append(death, pr.Death)
+		}
+	}
+
+	return birth, death
+}
+
+// NumSimplices returns the number of simplices in the filtration,
+// which is the number of simplices in the Delaunay triangulation that
+// are not flat.
+func (ac *AlphaComplex) NumSimplices() int {
+	return len(ac.simplices)
+}
+
+// Simplex returns the vertices and filtration value of the simplex at
+// position k in the filtration.
+func (ac *AlphaComplex) Simplex(k int) ([]int, float64) {
+	return ac.simplices[k], ac.values[k]
+}
+
+// BoundaryMatrix returns the boundary matrix of the filtration, which
+// can be reduced with other options.
+func (ac *AlphaComplex) BoundaryMatrix() *BoundaryMatrix {
+	return NewSimplicialBoundaryMatrix(ac.simplices, ac.values)
+}
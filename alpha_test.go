@@ -0,0 +1,351 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+// mstLengths returns the sorted edge lengths of a Euclidean minimum
+// spanning tree of the points in the rows of x.
+func mstLengths(x []float64, d int) []float64 {
+
+	n := len(x) / d
+	in := make([]bool, n)
+	best := make([]float64, n)
+	for i := range best {
+		best[i] = math.Inf(1)
+	}
+	best[0] = 0
+
+	var mst []float64
+	for k := 0; k < n; k++ {
+		u := -1
+		for i := range best {
+			if !in[i] && (u == -1 || best[i] < best[u]) {
+				u = i
+			}
+		}
+		in[u] = true
+		if k > 0 {
+			mst = append(mst, best[u])
+		}
+		for i := range best {
+			r := floats.Distance(x[u*d:u*d+d], x[i*d:i*d+d], 2)
+			if !in[i] && r < best[i] {
+				best[i] = r
+			}
+		}
+	}
+	sort.Float64s(mst)
+
+	return mst
+}
+
+func TestDelaunay(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(71))
+
+	for _, d := range []int{2, 3} {
+		for jt := 0; jt < 5; jt++ {
+
+			n := 10 + rng.Intn(30)
+			x := make([]float64, n*d)
+			for i := range x {
+				x[i] = rng.Float64()
+			}
+
+			// The triangulation of the convex hull has Euler
+			// characteristic 1.
+			simp := delaunay(x, d)
+			var euler int
+			for _, s := range simp {
+				if len(s)%2 == 1 {
+					euler++
+				} else {
+					euler--
+				}
+			}
+			if euler != 1 {
+				fmt.Printf("d=%d, test %d: Euler characteristic is %d\n", d, jt, euler)
+				t.Fail()
+			}
+
+			// The full-dimensional simplices have empty
+			// circumspheres.
+			for _, s := range simp {
+				if len(s) != d+1 {
+					continue
+				}
+				c, r2 := circumsphere(x, d, s)
+				for p := 0; p < n; p++ {
+					if !containsInt(s, p) && sqdist(x[p*d:p*d+d], c) < r2*(1-1e-10) {
+						fmt.Printf("d=%d, test %d: simplex %v is not Delaunay\n", d, jt, s)
+						t.Fail()
+					}
+				}
+			}
+		}
+	}
+}
+
+// The H0 death values are half of the edge lengths of a minimum
+// spanning tree.
+func TestAlphaH0(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(72))
+
+	for _, d := range []int{2, 3} {
+		for jt := 0; jt < 5; jt++ {
+
+			n := 10 + rng.Intn(30)
+			x := make([]float64, n*d)
+			for i := range x {
+				x[i] = rng.NormFloat64()
+			}
+
+			ac := NewAlphaComplex(x, d)
+			birth, death := ac.BirthDeath(0)
+			if !floats.Equal(birth, make([]float64, n)) {
+				fmt.Printf("d=%d, test %d: nonzero H0 births\n", d, jt)
+				t.Fail()
+			}
+
+			sort.Float64s(death)
+			mst := mstLengths(x, d)
+			floats.Scale(0.5, mst)
+			if len(death) != n || !floats.EqualApprox(death[0:n-1], mst, 1e-10) {
+				fmt.Printf("d=%d, test %d: H0 deaths do not match MST\n%v\n%v\n", d, jt, death, mst)
+				t.Fail()
+			}
+
+			// The values increase along the filtration, and
+			// each simplex follows its faces.
+			pos := make(map[[4]int]int)
+			for k := 0; k < ac.NumSimplices(); k++ {
+				s, v := ac.Simplex(k)
+				key := [4]int{-1, -1, -1, -1}
+				copy(key[:], s)
+				pos[key] = k
+				if k > 0 {
+					if _, v0 := ac.Simplex(k - 1); v < v0 {
+						fmt.Printf("d=%d, test %d: values out of order\n", d, jt)
+						t.Fail()
+					}
+				}
+				for i := range s {
+					if len(s) == 1 {
+						break
+					}
+					key := [4]int{-1, -1, -1, -1}
+					copy(key[:], s[0:i])
+					copy(key[i:], s[i+1:])
+					if j, ok := pos[key]; !ok || j >= k {
+						fmt.Printf("d=%d, test %d: face of %v is missing\n", d, jt, s)
+						t.Fail()
+					}
+				}
+			}
+		}
+	}
+}
+
+// The H0 deaths match the minimum spanning tree for larger point sets
+// that are elongated or concentrated near their convex hull, whose hull
+// simplices are nearly flat.
+func TestAlphaH0Hull(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(75))
+
+	for _, d := range []int{2, 3} {
+		for jt := 0; jt < 6; jt++ {
+
+			n := 100 + rng.Intn(200)
+			x := make([]float64, n*d)
+			for i := 0; i < n; i++ {
+				for j := 0; j < d; j++ {
+					x[i*d+j] = rng.NormFloat64()
+				}
+				switch jt % 3 {
+				case 0:
+					// A thin slab
+					x[i*d] *= 1000
+				case 1:
+					// A thin shell
+					var r float64
+					for j := 0; j < d; j++ {
+						r += x[i*d+j] * x[i*d+j]
+					}
+					r = math.Sqrt(r) / (1 + 0.001*rng.Float64())
+					for j := 0; j < d; j++ {
+						x[i*d+j] /= r
+					}
+				}
+			}
+
+			_, death := NewAlphaComplex(x, d).BirthDeath(0)
+			sort.Float64s(death)
+			mst := mstLengths(x, d)
+			floats.Scale(0.5, mst)
+			if len(death) != n || !floats.EqualApprox(death[0:n-1], mst, 1e-8) {
+				fmt.Printf("d=%d, test %d: H0 deaths do not match MST\n", d, jt)
+				t.Fail()
+			}
+		}
+	}
+}
+
+// Collinear points on the convex hull give flat simplices, which do
+// not enter the filtration, so all of the values are finite.
+func TestAlphaCollinear(t *testing.T) {
+
+	for jt, x := range [][]float64{
+		{0, 0, 1, 1, 2, 2, 3, 3, 0, 1},
+		{0, 0, 1, 0, 3, 0, 4, 0},
+		{0, 0, 0, 1, 1, 1, 2, 2, 2, 3, 3, 3},
+	} {
+		d := 2
+		if jt == 2 {
+			d = 3
+		}
+		n := len(x) / d
+
+		ac := NewAlphaComplex(x, d)
+		for k := 0; k < ac.NumSimplices(); k++ {
+			if _, v := ac.Simplex(k); math.IsInf(v, 0) {
+				fmt.Printf("Test %d: simplex %d has an infinite value\n", jt, k)
+				t.Fail()
+			}
+		}
+
+		// The component that never dies is given the greatest
+		// filtration value.
+		_, death := ac.BirthDeath(0)
+		sort.Float64s(death)
+		mst := mstLengths(x, d)
+		floats.Scale(0.5, mst)
+		_, end := ac.Simplex(ac.NumSimplices() - 1)
+		mst = append(mst, end)
+		if len(death) != n || !floats.EqualApprox(death, mst, 1e-10) {
+			fmt.Printf("Test %d: H0 deaths %v, expected %v\n", jt, death, mst)
+			t.Fail()
+		}
+	}
+}
+
+func TestAlphaCircle(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(73))
+
+	// Points near a unit circle form a loop, which is born at
+	// about half of the spacing between the points and dies when
+	// the disk is filled.
+	n := 24
+	var x []float64
+	for k := 0; k < n; k++ {
+		a := 2 * math.Pi * float64(k) / float64(n)
+		r := 1 + 0.01*rng.Float64()
+		x = append(x, r*math.Cos(a), r*math.Sin(a))
+	}
+
+	ac := NewAlphaComplex(x, 2)
+	birth, death := ac.BirthDeath(1)
+	if len(birth) != 1 || birth[0] > 0.15 || death[0] < 0.95 || death[0] > 1.05 {
+		fmt.Printf("Unexpected H1 pairs %v %v\n", birth, death)
+		t.Fail()
+	}
+}
+
+func TestAlphaSphere(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(74))
+
+	// Points in a thin shell enclose a void.  The radii are
+	// perturbed to avoid many points lying on a common sphere.
+	n := 200
+	var x []float64
+	for k := 0; k < n; k++ {
+		var p [3]float64
+		for j := range p {
+			p[j] = rng.NormFloat64()
+		}
+		r := math.Sqrt(p[0]*p[0] + p[1]*p[1] + p[2]*p[2])
+		r /= 0.9 + 0.1*rng.Float64()
+		x = append(x, p[0]/r, p[1]/r, p[2]/r)
+	}
+
+	ac := NewAlphaComplex(x, 3)
+
+	// The most persistent void should be born at a small scale
+	// and die when the ball is filled.
+	birth, death := ac.BirthDeath(2)
+	j := -1
+	for i := range birth {
+		if j == -1 || death[i]-birth[i] > death[j]-birth[j] {
+			j = i
+		}
+	}
+	if j == -1 || birth[j] > 0.5 || death[j] < 0.85 || death[j] > 1.05 {
+		fmt.Printf("Unexpected H2 pairs %v %v\n", birth, death)
+		t.Fail()
+	}
+	for i := range birth {
+		if i != j && death[i]-birth[i] > 0.2 {
+			fmt.Printf("Unexpected persistent void %v %v\n", birth[i], death[i])
+			t.Fail()
+		}
+	}
+}
+
+// Points on a grid are in degenerate position, but the persistence
+// diagram is still determined.  Each square of the grid is a loop that
+// is born when its sides appear and dies when its diagonals appear,
+// and each cube of the grid is a void that dies when it is filled.
+func TestAlphaGrid(t *testing.T) {
+
+	var x []float64
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 6; j++ {
+			x = append(x, float64(i), float64(j))
+		}
+	}
+
+	ac := NewAlphaComplex(x, 2)
+	birth, death := ac.BirthDeath(1)
+	if len(birth) != 25 {
+		fmt.Printf("Found %d loops, expected 25\n", len(birth))
+		t.Fail()
+	}
+	for i := range birth {
+		if birth[i] != 0.5 || math.Abs(death[i]-math.Sqrt2/2) > 1e-12 {
+			fmt.Printf("Unexpected loop %v %v\n", birth[i], death[i])
+			t.Fail()
+		}
+	}
+
+	x = x[0:0]
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			for k := 0; k < 4; k++ {
+				x = append(x, float64(i), float64(j), float64(k))
+			}
+		}
+	}
+
+	ac = NewAlphaComplex(x, 3)
+	birth, death = ac.BirthDeath(2)
+	if len(birth) != 27 {
+		fmt.Printf("Found %d voids, expected 27\n", len(birth))
+		t.Fail()
+	}
+	for i := range birth {
+		if math.Abs(birth[i]-math.Sqrt2/2) > 1e-12 || math.Abs(death[i]-math.Sqrt(3)/2) > 1e-12 {
+			fmt.Printf("Unexpected void %v %v\n", birth[i], death[i])
+			t.Fail()
+		}
+	}
+}
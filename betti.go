@@ -0,0 +1,152 @@
+package tda
+
+import (
+	"math"
+	"sort"
+)
+
+// BettiCurve is the number of intervals of a persistence diagram that
+// contain each point, which is the number of objects (or holes) that
+// are alive at each threshold.  The curve is a right-continuous step
+// function, and each interval is taken to include its left end point
+// but not its right end point.
+type BettiCurve struct {
+
+	// The points at which the curve changes value, in increasing
+	// order
+	x []float64
+
+	// The value of the curve from each change point up to the next
+	// change point.  The curve is zero before the first change
+	// point.
+	count []int
+}
+
+// NewBettiCurve returns the Betti curve of the diagram with the given
+// birth and death times.  Each interval runs from the lesser to the
+// greater of its birth and death times, and intervals of length zero
+// are ignored.  Death times may be infinite.
+func NewBettiCurve(birth, death []float64) *BettiCurve {
+
+	if len(birth) != len(death) {
+		panic("birth and death slices must have the same length")
+	}
+
+	type event struct {
+		x float64
+		c int
+	}
+	var ev []event
+	for i := range birth {
+		lo, hi := math.Min(birth[i], death[i]), math.Max(birth[i], death[i])
+		if lo == hi {
+			continue
+		}
+		ev = append(ev, event{lo, 1})
+		if !math.IsInf(hi, 1) {
+			ev = append(ev, event{hi, -1})
+		}
+	}
+	sort.Slice(ev, func(i, j int) bool { return ev[i].x < ev[j].x })
+
+	bc := &BettiCurve{}
+	c := 0
+	for k, e := range ev {
+		c += e.c
+		if k+1 < len(ev) && ev[k+1].x == e.x {
+			continue
+		}
+		if n := len(bc.count); n > 0 && bc.count[n-1] == c {
+			continue
+		}
+		bc.x = append(bc.x, e.x)
+		bc.count = append(bc.count, c)
+	}
+
+	return bc
+}
+
+// Steps returns the points at which the Betti curve changes value, and
+// the value of the curve from each of these points up to the next
+// one.  The curve is zero before the first point.  The returned slices
+// must not be modified.
+func (bc *BettiCurve) Steps() ([]float64, []int) {
+	return bc.x, bc.count
+}
+
+// Eval returns the value of the Betti curve at t.
+func (bc *BettiCurve) Eval(t float64) int {
+	k := sort.Search(len(bc.x), func(k int) bool { return bc.x[k] > t })
+	if k == 0 {
+		return 0
+	}
+	return bc.count[k-1]
+}
+
+// EvalGrid evaluates the Betti curve at each point in tvals.
+func (bc *BettiCurve) EvalGrid(tvals []float64) []int {
+	y := make([]int, len(tvals))
+	for i, t := range tvals {
+		y[i] = bc.Eval(t)
+	}
+	return y
+}
+
+// Integral returns the exact integral of the Betti curve, which is the
+// total length of the intervals.  The integral is +Inf if an interval
+// has an infinite death time.
+func (bc *BettiCurve) Integral() float64 {
+
+	var s float64
+	for k := 1; k < len(bc.x); k++ {
+		s += float64(bc.count[k-1]) * (bc.x[k] - bc.x[k-1])
+	}
+	if n := len(bc.count); n > 0 && bc.count[n-1] != 0 {
+		return math.Inf(1)
+	}
+
+	return s
+}
+
+// BettiCurve returns the number of objects in the thresholded image at
+// each threshold, as a step function of the threshold.  Each
+// trajectory contributes an interval from the threshold of its first
+// state to the next threshold after that of its last state, among the
+// thresholds of all states, so the value at each threshold is the
+// number of objects at that threshold, and the value is constant up
+// to the next threshold.  The objects at the greatest threshold are
+// taken to disappear at the next integer threshold, at which the
+// thresholded image is empty.
+func (ps *Persistence) BettiCurve() *BettiCurve {
+
+	// The distinct thresholds, in increasing order
+	var levels []int
+	for _, tr := range ps.traj {
+		for _, st := range tr {
+			levels = append(levels, st.Threshold)
+		}
+	}
+	sort.Ints(levels)
+	j := 0
+	for i, v := range levels {
+		if i == 0 || v != levels[j-1] {
+			levels[j] = v
+			j++
+		}
+	}
+	levels = levels[0:j]
+
+	var birth, death []float64
+	for _, tr := range ps.traj {
+		t0, t1 := tr[0].Threshold, tr[len(tr)-1].Threshold
+		birth = append(birth, float64(t0))
+		k := sort.SearchInts(levels, t1+1)
+		if k < len(levels) {
+			death = append(death, float64(levels[k]))
+		} else {
+			death = append(death, float64(t1+1))
+		}
+	}
+
+	return NewBettiCurve(birth, death)
+}
@@ -0,0 +1,104 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestBettiCurve(t *testing.T) {
+
+	bc := NewBettiCurve([]float64{0, 1, 2, 5}, []float64{3, 3, 2, math.Inf(1)})
+	x, c := bc.Steps()
+	if !reflect.DeepEqual(x, []float64{0, 1, 3, 5}) || !reflect.DeepEqual(c, []int{1, 2, 0, 1}) {
+		fmt.Printf("Unexpected steps %v %v\n", x, c)
+		t.Fail()
+	}
+	if y := bc.EvalGrid([]float64{-1, 0, 0.5, 1, 2.9, 3, 4, 5, 100}); !reflect.DeepEqual(y, []int{0, 1, 1, 2, 2, 0, 0, 1, 1}) {
+		fmt.Printf("Unexpected values %v\n", y)
+		t.Fail()
+	}
+	if !math.IsInf(bc.Integral(), 1) {
+		fmt.Printf("Integral is %v, expected +Inf\n", bc.Integral())
+		t.Fail()
+	}
+
+	rng := rand.New(rand.NewSource(181))
+
+	for jt := 0; jt < 30; jt++ {
+
+		n := 1 + rng.Intn(10)
+		birth := make([]float64, n)
+		death := make([]float64, n)
+		var length float64
+		for i := range birth {
+			birth[i] = float64(rng.Intn(10))
+			death[i] = birth[i] + float64(rng.Intn(5))
+			length += death[i] - birth[i]
+		}
+		bc := NewBettiCurve(birth, death)
+
+		for k := 0; k <= 60; k++ {
+			tv := -1 + float64(k)/4
+			var e int
+			for i := range birth {
+				if birth[i] <= tv && tv < death[i] {
+					e++
+				}
+			}
+			if y := bc.Eval(tv); y != e {
+				fmt.Printf("Test %d: Betti curve at %v is %d, expected %d\n", jt, tv, y, e)
+				t.Fail()
+			}
+		}
+		if bc.Integral() != length {
+			fmt.Printf("Test %d: integral is %v, expected %v\n", jt, bc.Integral(), length)
+			t.Fail()
+		}
+	}
+}
+
+// The Betti curve of the persistence trajectories counts the objects
+// in the thresholded images.
+func TestPersistenceBettiCurve(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(182))
+
+	for jt := 0; jt < 20; jt++ {
+
+		rows, cols := 5+rng.Intn(10), 5+rng.Intn(10)
+		img := make([]int, rows*cols)
+		for i := range img {
+			img[i] = rng.Intn(30)
+		}
+
+		var ps *Persistence
+		if jt%2 == 0 {
+			ps = NewPersistence(img, rows, 12)
+		} else {
+			ps = NewExactPersistence(img, rows)
+		}
+		bc := ps.BettiCurve()
+
+		mx := 0
+		for _, tr := range ps.Trajectories() {
+			for _, st := range tr {
+				th := st.Threshold
+				lbl := NewLabelPreserve(threshold(img, nil, th), rows, 8, nil)
+				if e := lbl.NumComponents() - 1; bc.Eval(float64(th)) != e {
+					fmt.Printf("Test %d: found %d objects at threshold %d, expected %d\n", jt, bc.Eval(float64(th)), th, e)
+					t.Fail()
+				}
+				if th > mx {
+					mx = th
+				}
+			}
+		}
+		if n := bc.Eval(float64(mx + 1)); n != 0 {
+			fmt.Printf("Test %d: found %d objects beyond the last threshold\n", jt, n)
+			t.Fail()
+		}
+	}
+}
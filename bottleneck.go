@@ -0,0 +1,363 @@
+package tda
+
+import (
+	"math"
+	"sort"
+)
+
+// DiagramMatch pairs a point of one persistence diagram with a point
+// of another persistence diagram, or with its projection onto the
+// diagonal.
+type DiagramMatch struct {
+
+	// The index of the point in the first diagram, or -1 if the
+	// point of the second diagram is matched to the diagonal.
+	First int
+
+	// The index of the point in the second diagram, or -1 if the
+	// point of the first diagram is matched to the diagonal.
+	Second int
+
+	// The distance between the matched points
+	Cost float64
+}
+
+// diagonalDist returns the distance in the maximum norm from a point
+// of a persistence diagram to its projection onto the diagonal, the
+// point ((b+d)/2, (b+d)/2).
+func diagonalDist(b, d float64) float64 {
+	return math.Abs(d-b) / 2
+}
+
+// maxDist returns the distance in the maximum norm between two points
+// of persistence diagrams.
+func maxDist(b1, d1, b2, d2 float64) float64 {
+	return math.Max(math.Abs(b1-b2), math.Abs(d1-d2))
+}
+
+// splitDiagram returns the indices of the points of a persistence
+// diagram with finite death times, and the indices of the points with
+// death times of +Inf and -Inf, each sorted by birth time.
+func splitDiagram(birth, death []float64) ([]int, []int, []int) {
+
+	if len(birth) != len(death) {
+		panic("birth and death slices must have the same length")
+	}
+
+	var fin, pinf, ninf []int
+	for i, d := range death {
+		switch {
+		case math.IsInf(d, 1):
+			pinf = append(pinf, i)
+		case math.IsInf(d, -1):
+			ninf = append(ninf, i)
+		default:
+			fin = append(fin, i)
+		}
+	}
+
+	sort.SliceStable(pinf, func(i, j int) bool { return birth[pinf[i]] < birth[pinf[j]] })
+	sort.SliceStable(ninf, func(i, j int) bool { return birth[ninf[i]] < birth[ninf[j]] })
+
+	return fin, pinf, ninf
+}
+
+// matchEssential matches the points of two diagrams with infinite
+// death times in order of birth time, which minimizes both the
+// greatest and the total difference of the birth times.  The second
+// return value is false if the numbers of points differ.
+func matchEssential(birth1, birth2 []float64, ix1, ix2 []int) ([]DiagramMatch, bool) {
+
+	if len(ix1) != len(ix2) {
+		return nil, false
+	}
+
+	m := make([]DiagramMatch, len(ix1))
+	for k := range ix1 {
+		i, j := ix1[k], ix2[k]
+		m[k] = DiagramMatch{First: i, Second: j, Cost: math.Abs(birth1[i] - birth2[j])}
+	}
+
+	return m, true
+}
+
+// diagramIndex supports finding the points of a persistence diagram
+// that are within a given distance of a point, in the maximum norm.
+type diagramIndex struct {
+	birth, death []float64
+
+	// The indices of the points, sorted by birth time
+	ix []int
+}
+
+func newDiagramIndex(birth, death []float64, ix []int) *diagramIndex {
+	ix = append([]int(nil), ix...)
+	sort.SliceStable(ix, func(i, j int) bool { return birth[ix[i]] < birth[ix[j]] })
+	return &diagramIndex{birth: birth, death: death, ix: ix}
+}
+
+// near appends to buf the indices of the points within distance r of
+// (b, d), and returns it.  Only the points with birth times in [b-r,
+// b+r] are examined.
+func (di *diagramIndex) near(b, d, r float64, buf []int) []int {
+	buf = buf[0:0]
+	k := sort.Search(len(di.ix), func(k int) bool { return di.birth[di.ix[k]] >= b-r })
+	for ; k < len(di.ix) && di.birth[di.ix[k]] <= b+r; k++ {
+		j := di.ix[k]
+		if maxDist(b, d, di.birth[j], di.death[j]) <= r {
+			buf = append(buf, j)
+		}
+	}
+	return buf
+}
+
+// bipartiteMatching finds a maximum matching in a bipartite graph
+// with nl left vertices and nr right vertices, using the algorithm of
+// Hopcroft and Karp.  The right vertices adjacent to left vertex i
+// are in adj[i].  The returned slice holds the right vertex matched
+// to each left vertex, or -1 if the left vertex is unmatched, and the
+// second return value is the size of the matching.
+func bipartiteMatching(nl, nr int, adj [][]int) ([]int, int) {
+
+	matchL := make([]int, nl)
+	matchR := make([]int, nr)
+	for i := range matchL {
+		matchL[i] = -1
+	}
+	for j := range matchR {
+		matchR[j] = -1
+	}
+
+	dist := make([]int, nl)
+	queue := make([]int, 0, nl)
+	inf := math.MaxInt32
+
+	// bfs layers the left vertices by the length of the shortest
+	// alternating path from an unmatched left vertex, and returns
+	// true if an augmenting path exists.
+	bfs := func() bool {
+		queue = queue[0:0]
+		for i := range matchL {
+			if matchL[i] == -1 {
+				dist[i] = 0
+				queue = append(queue, i)
+			} else {
+				dist[i] = inf
+			}
+		}
+		found := false
+		for q := 0; q < len(queue); q++ {
+			i := queue[q]
+			for _, j := range adj[i] {
+				k := matchR[j]
+				if k == -1 {
+					found = true
+				} else if dist[k] == inf {
+					dist[k] = dist[i] + 1
+					queue = append(queue, k)
+				}
+			}
+		}
+		return found
+	}
+
+	// dfs searches for an augmenting path from left vertex i
+	// along the layers.
+	var dfs func(i int) bool
+	dfs = func(i int) bool {
+		for _, j := range adj[i] {
+			k := matchR[j]
+			if k == -1 || (dist[k] == dist[i]+1 && dfs(k)) {
+				matchL[i] = j
+				matchR[j] = i
+				return true
+			}
+		}
+		dist[i] = inf
+		return false
+	}
+
+	size := 0
+	for bfs() {
+		for i := range matchL {
+			if matchL[i] == -1 && dfs(i) {
+				size++
+			}
+		}
+	}
+
+	return matchL, size
+}
+
+// Bottleneck returns the bottleneck distance between two persistence
+// diagrams, given as slices of birth and death times (e.g. as
+// returned by BirthDeath), and an optimal matching.  The bottleneck
+// distance is the least value of the greatest distance (in the
+// maximum norm) between matched points, over all matchings in which
+// points can also be matched to their projections onto the diagonal.
+// Points with infinite death times can only be matched to each other,
+// and if the two diagrams have different numbers of such points, the
+// distance is +Inf and the matching is nil.
+//
+// The distance is found exactly, by a binary search over the
+// candidate values with a maximum matching (Hopcroft and Karp) in the
+// graph of pairs that are within each candidate distance (Efrat,
+// Itai and Katz, 2001, Algorithmica 31:1).  Pairs of points that are
+// farther apart than both points are from the diagonal are never
+// considered, and the remaining pairs are found with a search on the
+// birth times.  The projections onto the diagonal are only linked
+// when the corresponding points are, so the graphs remain sparse.
+//
+// The matching contains every point of both diagrams exactly once, as
+// a pair of points or as a point matched to the diagonal.
+func Bottleneck(birth1, death1, birth2, death2 []float64) (float64, []DiagramMatch) {
+
+	fin1, pinf1, ninf1 := splitDiagram(birth1, death1)
+	fin2, pinf2, ninf2 := splitDiagram(birth2, death2)
+
+	ep, ok1 := matchEssential(birth1, birth2, pinf1, pinf2)
+	en, ok2 := matchEssential(birth1, birth2, ninf1, ninf2)
+	if !ok1 || !ok2 {
+		return math.Inf(1), nil
+	}
+
+	dist, m := bottleneckFinite(birth1, death1, birth2, death2, fin1, fin2)
+	m = append(m, ep...)
+	m = append(m, en...)
+	for _, e := range m {
+		dist = math.Max(dist, e.Cost)
+	}
+
+	return dist, m
+}
+
+// bottleneckFinite returns the bottleneck distance and an optimal
+// matching between the points of two diagrams with the given indices.
+func bottleneckFinite(birth1, death1, birth2, death2 []float64, ix1, ix2 []int) (float64, []DiagramMatch) {
+
+	n1, n2 := len(ix1), len(ix2)
+	if n1+n2 == 0 {
+		return 0, nil
+	}
+
+	diag1 := make([]float64, n1)
+	for k, i := range ix1 {
+		diag1[k] = diagonalDist(birth1[i], death1[i])
+	}
+	diag2 := make([]float64, n2)
+	var mx2 float64
+	for l, j := range ix2 {
+		diag2[l] = diagonalDist(birth2[j], death2[j])
+		mx2 = math.Max(mx2, diag2[l])
+	}
+
+	// The position of each point among the points being matched
+	pos2 := make(map[int]int, n2)
+	for l, j := range ix2 {
+		pos2[j] = l
+	}
+
+	// The pairs of points that can be matched.  A pair that is
+	// farther apart than both points are from the diagonal is
+	// never needed, since matching both points to the diagonal
+	// instead does not increase the cost.  The pairs are found with
+	// a search on the birth times.
+	type edge struct {
+		l    int
+		cost float64
+	}
+	pairs := make([][]edge, n1)
+	di := newDiagramIndex(birth2, death2, ix2)
+	var buf []int
+	for k, i := range ix1 {
+		buf = di.near(birth1[i], death1[i], math.Max(diag1[k], mx2), buf)
+		for _, j := range buf {
+			l := pos2[j]
+			c := maxDist(birth1[i], death1[i], birth2[j], death2[j])
+			if c <= math.Max(diag1[k], diag2[l]) {
+				pairs[k] = append(pairs[k], edge{l, c})
+			}
+		}
+	}
+
+	// The candidate values are the distances to the diagonal and
+	// the distances between the pairs that can be matched.
+	cand := []float64{0}
+	cand = append(cand, diag1...)
+	cand = append(cand, diag2...)
+	for _, pk := range pairs {
+		for _, e := range pk {
+			cand = append(cand, e.cost)
+		}
+	}
+	sort.Float64s(cand)
+	u := 1
+	for k := 1; k < len(cand); k++ {
+		if cand[k] != cand[u-1] {
+			cand[u] = cand[k]
+			u++
+		}
+	}
+	cand = cand[0:u]
+
+	// The left vertices are the points of the first diagram,
+	// followed by the projections of the points of the second
+	// diagram.  The right vertices are the points of the second
+	// diagram, followed by the projections of the points of the
+	// first diagram.  The projections of two points can be matched
+	// at no cost if the points can be matched to each other, which
+	// suffices to complete any matching of the points.
+	n := n1 + n2
+	graph := func(r float64) [][]int {
+		adj := make([][]int, n)
+		for k, pk := range pairs {
+			for _, e := range pk {
+				if e.cost <= r {
+					adj[k] = append(adj[k], e.l)
+					adj[n1+e.l] = append(adj[n1+e.l], n2+k)
+				}
+			}
+			if diag1[k] <= r {
+				adj[k] = append(adj[k], n2+k)
+			}
+		}
+		for l := range ix2 {
+			if diag2[l] <= r {
+				adj[n1+l] = append(adj[n1+l], l)
+			}
+		}
+		return adj
+	}
+
+	// Find the least candidate value that admits a perfect
+	// matching, the greatest candidate always does.
+	lo, hi := 0, len(cand)-1
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if _, size := bipartiteMatching(n, n, graph(cand[mid])); size == n {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+
+	r := cand[lo]
+	matchL, _ := bipartiteMatching(n, n, graph(r))
+
+	var m []DiagramMatch
+	for k, i := range ix1 {
+		if l := matchL[k]; l < n2 {
+			j := ix2[l]
+			m = append(m, DiagramMatch{First: i, Second: j, Cost: maxDist(birth1[i], death1[i], birth2[j], death2[j])})
+		} else {
+			m = append(m, DiagramMatch{First: i, Second: -1, Cost: diagonalDist(birth1[i], death1[i])})
+		}
+	}
+	for k, j := range ix2 {
+		if matchL[n1+k] == k {
+			m = append(m, DiagramMatch{First: -1, Second: j, Cost: diagonalDist(birth2[j], death2[j])})
+		}
+	}
+
+	return r, m
+}
@@ -0,0 +1,183 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// augmentedCosts returns the cost matrix of the matching problem
+// between two diagrams, in which each diagram is augmented with the
+// projections of the points of the other diagram.
+func augmentedCosts(birth1, death1, birth2, death2 []float64) [][]float64 {
+
+	n1, n2 := len(birth1), len(birth2)
+	n := n1 + n2
+	c := make([][]float64, n)
+	for i := range c {
+		c[i] = make([]float64, n)
+		for j := range c[i] {
+			switch {
+			case i < n1 && j < n2:
+				c[i][j] = maxDist(birth1[i], death1[i], birth2[j], death2[j])
+			case i < n1:
+				c[i][j] = math.Inf(1)
+				if j-n2 == i {
+					c[i][j] = diagonalDist(birth1[i], death1[i])
+				}
+			case j < n2:
+				c[i][j] = math.Inf(1)
+				if i-n1 == j {
+					c[i][j] = diagonalDist(birth2[j], death2[j])
+				}
+			}
+		}
+	}
+
+	return c
+}
+
+// permutations calls f with every permutation of 0, ..., n-1.
+func permutations(n int, f func([]int)) {
+	p := make([]int, n)
+	for i := range p {
+		p[i] = i
+	}
+	var rec func(k int)
+	rec = func(k int) {
+		if k == n {
+			f(p)
+			return
+		}
+		for i := k; i < n; i++ {
+			p[k], p[i] = p[i], p[k]
+			rec(k + 1)
+			p[k], p[i] = p[i], p[k]
+		}
+	}
+	rec(0)
+}
+
+func randomDiagram(rng *rand.Rand, n int) ([]float64, []float64) {
+	birth := make([]float64, n)
+	death := make([]float64, n)
+	for i := range birth {
+		birth[i] = float64(rng.Intn(10))
+		death[i] = birth[i] + float64(rng.Intn(6))
+	}
+	return birth, death
+}
+
+// checkMatching returns an error message if the matching does not
+// contain each point of both diagrams exactly once, or an empty
+// string.
+func checkMatching(m []DiagramMatch, n1, n2 int) string {
+	c1 := make([]int, n1)
+	c2 := make([]int, n2)
+	for _, e := range m {
+		if e.First == -1 && e.Second == -1 {
+			return "diagonal matched to diagonal"
+		}
+		if e.First != -1 {
+			c1[e.First]++
+		}
+		if e.Second != -1 {
+			c2[e.Second]++
+		}
+	}
+	for _, c := range append(c1, c2...) {
+		if c != 1 {
+			return "point is not matched exactly once"
+		}
+	}
+	return ""
+}
+
+func TestBottleneck(t *testing.T) {
+
+	// The first point is matched to the second point, and the
+	// remaining point is matched to the diagonal.
+	b1, d1 := []float64{0, 3}, []float64{10, 4}
+	b2, d2 := []float64{1}, []float64{12}
+	dist, m := Bottleneck(b1, d1, b2, d2)
+	if dist != 2 {
+		fmt.Printf("Found distance %v, expected 2\n", dist)
+		t.Fail()
+	}
+	expected := []DiagramMatch{{0, 0, 2}, {1, -1, 0.5}}
+	if fmt.Sprint(m) != fmt.Sprint(expected) {
+		fmt.Printf("Unexpected matching %v\n", m)
+		t.Fail()
+	}
+
+	// Points with infinite death times
+	b1, d1 = []float64{0, 3, 1}, []float64{math.Inf(1), 4, math.Inf(1)}
+	b2, d2 = []float64{2, 5}, []float64{math.Inf(1), math.Inf(1)}
+	if dist, m = Bottleneck(b1, d1, b2, d2); dist != 4 || checkMatching(m, 3, 2) != "" {
+		fmt.Printf("Found distance %v, expected 4\n", dist)
+		t.Fail()
+	}
+	if dist, m = Bottleneck(b1, d1, b2[0:1], d2[0:1]); !math.IsInf(dist, 1) || m != nil {
+		fmt.Printf("Found distance %v, expected +Inf\n", dist)
+		t.Fail()
+	}
+
+	if dist, m = Bottleneck(nil, nil, nil, nil); dist != 0 || len(m) != 0 {
+		fmt.Printf("Empty diagrams have distance %v\n", dist)
+		t.Fail()
+	}
+}
+
+// The bottleneck distance agrees with a search over all matchings of
+// small diagrams.
+func TestBottleneckBrute(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(111))
+
+	for jt := 0; jt < 100; jt++ {
+
+		b1, d1 := randomDiagram(rng, rng.Intn(4))
+		b2, d2 := randomDiagram(rng, rng.Intn(4))
+
+		c := augmentedCosts(b1, d1, b2, d2)
+		best := math.Inf(1)
+		permutations(len(c), func(p []int) {
+			var v float64
+			for i, j := range p {
+				v = math.Max(v, c[i][j])
+			}
+			best = math.Min(best, v)
+		})
+		if len(c) == 0 {
+			best = 0
+		}
+
+		dist, m := Bottleneck(b1, d1, b2, d2)
+		if dist != best {
+			fmt.Printf("Test %d: found distance %v, expected %v\n", jt, dist, best)
+			t.Fail()
+		}
+		if msg := checkMatching(m, len(b1), len(b2)); msg != "" {
+			fmt.Printf("Test %d: %s\n", jt, msg)
+			t.Fail()
+		}
+		var mx float64
+		for _, e := range m {
+			mx = math.Max(mx, e.Cost)
+		}
+		if mx != dist {
+			fmt.Printf("Test %d: matching has cost %v, expected %v\n", jt, mx, dist)
+			t.Fail()
+		}
+
+		if d, _ := Bottleneck(b2, d2, b1, d1); d != dist {
+			fmt.Printf("Test %d: distance is not symmetric\n", jt)
+			t.Fail()
+		}
+		if d, _ := Bottleneck(b1, d1, b1, d1); d != 0 {
+			fmt.Printf("Test %d: diagram has distance %v to itself\n", jt, d)
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package tda
+
+import (
+	"sort"
+
+	"github.com/theodesp/unionfind"
+)
+
+// ContourNode is a critical point in a contour tree.
+type ContourNode struct {
+
+	// The kind of critical point.  Maxima have no arcs leading up,
+	// minima have no arcs leading down, and saddles have more than
+	// one arc leading up or down.
+	Type NodeType
+
+	// The linear index of the pixel at the critical point, and its
+	// intensity
+	Pixel int
+	Value int
+}
+
+// ContourArc is an arc of a contour tree, joining two critical points.
+type ContourArc struct {
+
+	// The indices of the nodes at the upper (higher value) and
+	// lower ends of the arc.
+	Upper int
+	Lower int
+
+	// The linear indices of the (regular) pixels whose contours
+	// lie on the arc, in order of decreasing value.  After
+	// simplification, this includes the pixels of the arcs that
+	// were removed, so the values may fall outside of the range
+	// spanned by the two ends of the arc.
+	Pixels []int
+}
+
+// ContourTree is the contour tree of an image viewed as a scalar
+// field.  The contour tree tracks the connected components of the
+// level sets {img = t}, combining the peaks summarized by the merge
+// (join) tree of the superlevel sets with the basins summarized by the
+// split tree of the sublevel sets.  Since the image domain is simply
+// connected, the contour tree coincides with the Reeb graph.
+type ContourTree struct {
+
+	// The dimensions of the image
+	rows int
+	cols int
+
+	// The original image being processed
+	img []int
+
+	// The critical points
+	nodes []ContourNode
+
+	// The arcs joining the critical points
+	arcs []ContourArc
+}
+
+// NewContourTree constructs the contour tree of the given image,
+// which must be rectangular with the given number of rows, using the
+// algorithm of Carr, Snoeyink and Axen (2003), Computational
+// Geometry, 24:2.  The image is treated as a piecewise linear function
+// on a triangulation of the pixel grid in which each pixel is adjacent
+// to its four horizontal and vertical neighbors and to its upper left
+// and lower right diagonal neighbors.  Ties among pixel intensities
+// are broken using the linear pixel index, which can produce critical
+// points with zero persistence on plateaus.  These can be removed
+// with Simplify(0).
+func NewContourTree(img []int, rows int) *ContourTree {
+
+	cols := len(img) / rows
+	if rows*cols != len(img) {
+		panic("rows is not compatible with img")
+	}
+
+	ct := &ContourTree{
+		rows: rows,
+		cols: cols,
+		img:  img,
+	}
+
+	ct.build()
+
+	return ct
+}
+
+// neighbors6 returns the neighbors of pixel p in the triangulated grid.
+func (ct *ContourTree) neighbors6(p int, buf []int) []int {
+
+	buf = buf[0:0]
+	i, j := p/ct.cols, p%ct.cols
+	for _, d := range [6][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}, {-1, -1}, {1, 1}} {
+		i1, j1 := i+d[0], j+d[1]
+		if i1 >= 0 && i1 < ct.rows && j1 >= 0 && j1 < ct.cols {
+			buf = append(buf, i1*ct.cols+j1)
+		}
+	}
+
+	return buf
+}
+
+// removeInt deletes the first occurrence of x from a.
+func removeInt(a []int, x int) []int {
+	for i, v := range a {
+		if v == x {
+			return append(a[0:i], a[i+1:]...)
+		}
+	}
+	return a
+}
+
+// replaceInt replaces the first occurrence of x in a with y.
+func replaceInt(a []int, x, y int) {
+	for i, v := range a {
+		if v == x {
+			a[i] = y
+			return
+		}
+	}
+}
+
+func (ct *ContourTree) build() {
+
+	img := ct.img
+	n := len(img)
+	if n == 0 {
+		return
+	}
+
+	// The pixels in increasing order, with ties broken by index,
+	// and the rank of each pixel in this order.
+	ord := make([]int, n)
+	for i := range ord {
+		ord[i] = i
+	}
+	sort.SliceStable(ord, func(i, j int) bool {
+		return img[ord[i]] < img[ord[j]]
+	})
+	rank := make([]int, n)
+	for k, p := range ord {
+		rank[p] = k
+	}
+
+	// The join tree, with jup holding the neighbors above each
+	// pixel and jdown holding the neighbor below (or -1).
+	jup := make([][]int, n)
+	jdown := make([]int, n)
+
+	// The split tree, with sdown holding the neighbors below each
+	// pixel and sup holding the neighbor above (or -1).
+	sdown := make([][]int, n)
+	sup := make([]int, n)
+
+	var nbr []int
+	for pass := 0; pass < 2; pass++ {
+
+		uf := unionfind.New(n)
+
+		// The most recently added pixel in each component
+		last := make([]int, n)
+
+		for k := 0; k < n; k++ {
+
+			// The join tree is built from the top down, and
+			// the split tree from the bottom up.
+			var p int
+			if pass == 0 {
+				p = ord[n-1-k]
+				jdown[p] = -1
+			} else {
+				p = ord[k]
+				sup[p] = -1
+			}
+			last[p] = p
+
+			nbr = ct.neighbors6(p, nbr)
+			for _, q := range nbr {
+				if (pass == 0 && rank[q] < rank[p]) || (pass == 1 && rank[q] > rank[p]) {
+					continue
+				}
+				rq := uf.Find(q)
+				rp := uf.Find(p)
+				if rq == rp {
+					continue
+				}
+				u := last[rq]
+				if pass == 0 {
+					jup[p] = append(jup[p], u)
+					jdown[u] = p
+				} else {
+					sdown[p] = append(sdown[p], u)
+					sup[u] = p
+				}
+				uf.Union(rp, rq)
+				last[uf.Find(p)] = p
+			}
+		}
+	}
+
+	// Merge the join and split trees into the augmented contour
+	// tree, by repeatedly removing leaves.
+	cup := make([][]int, n)
+	cdown := make([][]int, n)
+	removed := make([]bool, n)
+
+	isLeaf := func(p int) bool {
+		return !removed[p] && len(jup[p])+len(sdown[p]) == 1
+	}
+
+	var queue []int
+	for p := 0; p < n; p++ {
+		if isLeaf(p) {
+			queue = append(queue, p)
+		}
+	}
+
+	remaining := n
+	for len(queue) > 0 && remaining > 1 {
+
+		p := queue[0]
+		queue = queue[1:]
+		if !isLeaf(p) {
+			continue
+		}
+
+		var w, u, d int
+		if len(jup[p]) == 0 {
+			// An upper leaf, which is attached to the pixel
+			// below it in the join tree.
+			w = jdown[p]
+			cup[w] = append(cup[w], p)
+			cdown[p] = append(cdown[p], w)
+			jup[w] = removeInt(jup[w], p)
+
+			// Splice the pixel out of the split tree
+			d, u = sdown[p][0], sup[p]
+			sup[d] = u
+			if u != -1 {
+				replaceInt(sdown[u], p, d)
+			}
+		} else {
+			// A lower leaf, which is attached to the pixel
+			// above it in the split tree.
+			w = sup[p]
+			cdown[w] = append(cdown[w], p)
+			cup[p] = append(cup[p], w)
+			sdown[w] = removeInt(sdown[w], p)
+
+			// Splice the pixel out of the join tree
+			u, d = jup[p][0], jdown[p]
+			jdown[u] = d
+			if d != -1 {
+				replaceInt(jup[d], p, u)
+			}
+		}
+
+		removed[p] = true
+		remaining--
+		for _, q := range []int{w, u, d} {
+			if q != -1 && isLeaf(q) {
+				queue = append(queue, q)
+			}
+		}
+	}
+
+	// Reduce the augmented tree to the critical points.
+	node := make([]int, n)
+	for p := range node {
+		node[p] = -1
+		if len(cup[p]) == 1 && len(cdown[p]) == 1 {
+			continue
+		}
+		nd := ContourNode{Type: SaddleNode, Pixel: p, Value: img[p]}
+		switch {
+		case len(cup[p]) == 0:
+			nd.Type = MaxNode
+		case len(cdown[p]) == 0:
+			nd.Type = MinNode
+		}
+		node[p] = len(ct.nodes)
+		ct.nodes = append(ct.nodes, nd)
+	}
+
+	for _, nd := range ct.nodes {
+		for _, x := range cdown[nd.Pixel] {
+			var pix []int
+			for node[x] == -1 {
+				pix = append(pix, x)
+				x = cdown[x][0]
+			}
+			ct.arcs = append(ct.arcs, ContourArc{
+				Upper:  node[nd.Pixel],
+				Lower:  node[x],
+				Pixels: pix,
+			})
+		}
+	}
+}
+
+// Nodes returns the critical points of the contour tree.
+func (ct *ContourTree) Nodes() []ContourNode {
+	return ct.nodes
+}
+
+// Arcs returns the arcs of the contour tree.
+func (ct *ContourTree) Arcs() []ContourArc {
+	return ct.arcs
+}
+
+// Simplify returns a simplified contour tree, in which leaf arcs with
+// persistence (the difference between the values at the two ends of
+// the arc) no greater than eps are removed, starting with the least
+// persistent.  A leaf arc leading down from a maximum is removed only
+// if its saddle has another arc leading up, and a leaf arc leading up
+// from a minimum is removed only if its saddle has another arc leading
+// down.  Saddles that are left with one arc leading up and one arc
+// leading down become regular points, and their two arcs are joined.
+// The pixels on a removed arc are assigned to another arc on the same
+// side of its saddle.
+func (ct *ContourTree) Simplify(eps int) *ContourTree {
+
+	nodes := make([]ContourNode, len(ct.nodes))
+	copy(nodes, ct.nodes)
+	arcs := make([]ContourArc, len(ct.arcs))
+	for i, a := range ct.arcs {
+		arcs[i] = a
+		arcs[i].Pixels = append([]int(nil), a.Pixels...)
+	}
+
+	// The arcs leading up and down from each node
+	up := make([][]int, len(nodes))
+	down := make([][]int, len(nodes))
+	for i, a := range arcs {
+		down[a.Upper] = append(down[a.Upper], i)
+		up[a.Lower] = append(up[a.Lower], i)
+	}
+	alive := make([]bool, len(nodes))
+	for i := range alive {
+		alive[i] = true
+	}
+	arcAlive := make([]bool, len(arcs))
+	for i := range arcAlive {
+		arcAlive[i] = true
+	}
+
+	for {
+		// Find the least persistent removable leaf arc
+		best := -1
+		var bp int
+		for i, a := range arcs {
+			if !arcAlive[i] {
+				continue
+			}
+			u, l := a.Upper, a.Lower
+			leafUp := len(up[u]) == 0 && len(down[u]) == 1 && len(up[l]) >= 2
+			leafDown := len(down[l]) == 0 && len(up[l]) == 1 && len(down[u]) >= 2
+			if !leafUp && !leafDown {
+				continue
+			}
+			pers := nodes[u].Value - nodes[l].Value
+			if pers <= eps && (best == -1 || pers < bp) {
+				best, bp = i, pers
+			}
+		}
+		if best == -1 {
+			break
+		}
+
+		// Remove the leaf arc and its leaf node, and move its
+		// pixels to a sibling arc.
+		a := arcs[best]
+		arcAlive[best] = false
+		var s, leaf int
+		var sib []int
+		if len(up[a.Upper]) == 0 && len(down[a.Upper]) == 1 && len(up[a.Lower]) >= 2 {
+			leaf, s = a.Upper, a.Lower
+			up[s] = removeInt(up[s], best)
+			down[leaf] = nil
+			sib = up[s]
+		} else {
+			leaf, s = a.Lower, a.Upper
+			down[s] = removeInt(down[s], best)
+			up[leaf] = nil
+			sib = down[s]
+		}
+		alive[leaf] = false
+		k := sib[0]
+		arcs[k].Pixels = append(arcs[k].Pixels, a.Pixels...)
+		arcs[k].Pixels = append(arcs[k].Pixels, nodes[leaf].Pixel)
+
+		// Join the arcs at a saddle that has become regular
+		if len(up[s]) == 1 && len(down[s]) == 1 {
+			ia, ib := up[s][0], down[s][0]
+			arcs[ia].Lower = arcs[ib].Lower
+			arcs[ia].Pixels = append(arcs[ia].Pixels, nodes[s].Pixel)
+			arcs[ia].Pixels = append(arcs[ia].Pixels, arcs[ib].Pixels...)
+			replaceInt(up[arcs[ib].Lower], ib, ia)
+			arcAlive[ib] = false
+			alive[s] = false
+			up[s], down[s] = nil, nil
+		}
+	}
+
+	// Compact the nodes and arcs
+	nct := &ContourTree{
+		rows: ct.rows,
+		cols: ct.cols,
+		img:  ct.img,
+	}
+	idx := make([]int, len(nodes))
+	for i, nd := range nodes {
+		if alive[i] {
+			idx[i] = len(nct.nodes)
+			nct.nodes = append(nct.nodes, nd)
+		}
+	}
+	for i, a := range arcs {
+		if arcAlive[i] {
+			a.Upper = idx[a.Upper]
+			a.Lower = idx[a.Lower]
+			sort.SliceStable(a.Pixels, func(i, j int) bool {
+				return ct.img[a.Pixels[i]] > ct.img[a.Pixels[j]]
+			})
+			nct.arcs = append(nct.arcs, a)
+		}
+	}
+
+	return nct
+}
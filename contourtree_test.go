@@ -0,0 +1,179 @@
+package tda
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestContourTree(t *testing.T) {
+
+	img := []int{
+		0, 0, 0, 0, 0, 0, 0,
+		0, 9, 8, 3, 6, 7, 0,
+		0, 0, 0, 0, 0, 0, 0,
+	}
+
+	// Remove the zero persistence features on the plateau
+	ct := NewContourTree(img, 3).Simplify(0)
+	nodes := ct.Nodes()
+	arcs := ct.Arcs()
+
+	if len(nodes) != 4 || len(arcs) != 3 {
+		fmt.Printf("Found %d nodes and %d arcs, expected 4 and 3\n%+v\n%+v\n",
+			len(nodes), len(arcs), nodes, arcs)
+		t.FailNow()
+	}
+
+	expected := []ContourNode{
+		{Type: MinNode, Pixel: 0, Value: 0},
+		{Type: MaxNode, Pixel: 8, Value: 9},
+		{Type: SaddleNode, Pixel: 10, Value: 3},
+		{Type: MaxNode, Pixel: 12, Value: 7},
+	}
+	for k, e := range expected {
+		if nodes[k] != e {
+			fmt.Printf("Node %d is %+v, expected %+v\n", k, nodes[k], e)
+			t.Fail()
+		}
+	}
+
+	// The arcs, and the number of pixels on each
+	earcs := map[[2]int]int{{1, 2}: 1, {3, 2}: 1, {2, 0}: 15}
+	for _, a := range arcs {
+		n, ok := earcs[[2]int{a.Upper, a.Lower}]
+		if !ok || n != len(a.Pixels) {
+			fmt.Printf("Unexpected arc %+v\n", a)
+			t.Fail()
+		}
+	}
+
+	// The pixels on the arcs between the maxima and the saddle
+	for _, a := range arcs {
+		if a.Upper == 1 && a.Pixels[0] != 9 {
+			fmt.Printf("Unexpected pixels %v\n", a.Pixels)
+			t.Fail()
+		}
+		if a.Upper == 3 && a.Pixels[0] != 11 {
+			fmt.Printf("Unexpected pixels %v\n", a.Pixels)
+			t.Fail()
+		}
+	}
+
+	// Removing the lower peak leaves a single arc
+	ct = ct.Simplify(4)
+	if len(ct.Nodes()) != 2 || len(ct.Arcs()) != 1 || len(ct.Arcs()[0].Pixels) != 19 {
+		fmt.Printf("Unexpected simplified tree:\n%+v\n%+v\n", ct.Nodes(), ct.Arcs())
+		t.Fail()
+	}
+}
+
+// checkContourTree checks that the contour tree is a tree in which
+// the arcs lead downward, and that every pixel is either a node or
+// lies on exactly one arc.  If strict is true, the pixels on each arc
+// must have values between the values at its ends, which need not hold
+// after simplification.
+func checkContourTree(ct *ContourTree, img []int, strict bool) error {
+
+	nodes, arcs := ct.Nodes(), ct.Arcs()
+	if len(arcs) != len(nodes)-1 {
+		return fmt.Errorf("%d nodes and %d arcs", len(nodes), len(arcs))
+	}
+
+	seen := make([]int, len(img))
+	for _, nd := range nodes {
+		seen[nd.Pixel]++
+	}
+
+	// Check connectivity using the arcs
+	comp := make([]int, len(nodes))
+	for i := range comp {
+		comp[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if comp[i] != i {
+			comp[i] = find(comp[i])
+		}
+		return comp[i]
+	}
+
+	up := make([]int, len(nodes))
+	down := make([]int, len(nodes))
+	for _, a := range arcs {
+		hi, lo := nodes[a.Upper].Value, nodes[a.Lower].Value
+		if hi < lo {
+			return fmt.Errorf("arc %+v leads upward", a)
+		}
+		for _, p := range a.Pixels {
+			seen[p]++
+			if strict && (img[p] > hi || img[p] < lo) {
+				return fmt.Errorf("pixel %d is outside of arc %+v", p, a)
+			}
+		}
+		comp[find(a.Upper)] = find(a.Lower)
+		down[a.Upper]++
+		up[a.Lower]++
+	}
+
+	for i := range nodes {
+		if find(i) != find(0) {
+			return fmt.Errorf("the tree is not connected")
+		}
+		var tp NodeType
+		switch {
+		case up[i] == 0:
+			tp = MaxNode
+		case down[i] == 0:
+			tp = MinNode
+		default:
+			tp = SaddleNode
+			if up[i] == 1 && down[i] == 1 {
+				return fmt.Errorf("node %d is regular", i)
+			}
+		}
+		if nodes[i].Type != tp {
+			return fmt.Errorf("node %d has the wrong type", i)
+		}
+	}
+
+	for p, n := range seen {
+		if n != 1 {
+			return fmt.Errorf("pixel %d appears %d times", p, n)
+		}
+	}
+
+	return nil
+}
+
+func TestContourTreeRandom(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(52))
+
+	for jt := 0; jt < 20; jt++ {
+
+		rows, cols := 1+rng.Intn(10), 1+rng.Intn(10)
+		img := make([]int, rows*cols)
+		for i := range img {
+			img[i] = rng.Intn(10)
+		}
+
+		ct := NewContourTree(img, rows)
+		if err := checkContourTree(ct, img, true); err != nil {
+			fmt.Printf("Test %d: %v\n", jt, err)
+			t.Fail()
+		}
+
+		for _, eps := range []int{0, 2, 5, 10} {
+			s := ct.Simplify(eps)
+			if err := checkContourTree(s, img, false); err != nil {
+				fmt.Printf("Test %d, eps=%d: %v\n", jt, eps, err)
+				t.Fail()
+			}
+			if len(s.Nodes()) > len(ct.Nodes()) {
+				fmt.Printf("Test %d, eps=%d: simplification added nodes\n", jt, eps)
+				t.Fail()
+			}
+		}
+	}
+}
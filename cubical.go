@@ -0,0 +1,210 @@
+package tda
+
+import (
+	"sort"
+
+	"github.com/theodesp/unionfind"
+)
+
+// HolePair describes the lifespan of a one-dimensional feature (a
+// loop surrounding a hole) in the superlevel sets of an image.
+type HolePair struct {
+
+	// The least threshold at which the hole is present.
+	Birth int
+
+	// The greatest threshold at which the hole is present.
+	Death int
+
+	// The linear index of the darkest pixel in the hole.  The
+	// hole is filled when the threshold falls to the intensity of
+	// this pixel.
+	Bottom int
+
+	// The linear index of the pixel that closes the loop around
+	// the hole.  The loop is broken when the threshold exceeds
+	// the intensity of this pixel.
+	Saddle int
+}
+
+// CubicalPersistence calculates the one-dimensional persistence
+// (holes or loops) of the superlevel sets of an image.
+type CubicalPersistence struct {
+
+	// The dimensions of the image
+	rows int
+	cols int
+
+	// The original image being processed
+	img []int
+
+	// The hole lifespans
+	pairs []HolePair
+}
+
+// NewCubicalPersistence calculates the one-dimensional persistence
+// of the superlevel sets {img >= t} of the given image, which must be
+// rectangular with the given number of rows.  The image is treated as
+// a cubical complex in which each pixel is a square cell, so that
+// pixels touching at a corner are connected (8-connectivity), as in
+// NewExactPersistence.
+//
+// By Alexander duality, the holes in a superlevel set correspond to
+// the 4-connected components of its complement that do not touch the
+// image border.  These components are tracked over all distinct
+// thresholds in a single pass over the pixels in order of increasing
+// intensity using a union-find structure.  When two components of
+// the complement merge, the younger one (with the brighter bottom)
+// ceases to be a hole.
+func NewCubicalPersistence(img []int, rows int) *CubicalPersistence {
+
+	cols := len(img) / rows
+	if rows*cols != len(img) {
+		panic("rows is not compatible with img")
+	}
+
+	cp := &CubicalPersistence{
+		rows: rows,
+		cols: cols,
+		img:  img,
+	}
+
+	cp.run()
+
+	return cp
+}
+
+func (cp *CubicalPersistence) run() {
+
+	img := cp.img
+	rows, cols := cp.rows, cp.cols
+	n := len(img)
+
+	// Order the pixels by increasing intensity.
+	ord := make([]int, n)
+	for i := range ord {
+		ord[i] = i
+	}
+	sort.SliceStable(ord, func(i, j int) bool {
+		return img[ord[i]] < img[ord[j]]
+	})
+
+	levels := make([]int, 0, 100)
+	for _, p := range ord {
+		if len(levels) == 0 || img[p] != levels[len(levels)-1] {
+			levels = append(levels, img[p])
+		}
+	}
+
+	// Position n is a virtual pixel representing the region
+	// outside of the image, which is part of every complement.
+	outside := n
+	uf := unionfind.New(n + 1)
+	bottom := make([]int, n+1)
+	bottom[outside] = -1
+	added := make([]bool, n)
+
+	// older returns true if the component with bottom pixel a was
+	// born before the component with bottom pixel b.
+	older := func(a, b int) bool {
+		if a == -1 || b == -1 {
+			return a == -1
+		}
+		if img[a] != img[b] {
+			return img[a] < img[b]
+		}
+		return a < b
+	}
+
+	merge := func(p, q int) {
+		rp := uf.Find(p)
+		rq := uf.Find(q)
+		if rp == rq {
+			return
+		}
+		bp, bq := bottom[rp], bottom[rq]
+		if older(bq, bp) {
+			bp, bq = bq, bp
+		}
+
+		// The component with bottom pixel bq dies when the
+		// threshold exceeds the intensity of pixel p.
+		v := img[p]
+		if img[bq] < v {
+			k := sort.SearchInts(levels, img[bq])
+			cp.pairs = append(cp.pairs, HolePair{
+				Birth:  levels[k+1],
+				Death:  v,
+				Bottom: bq,
+				Saddle: p,
+			})
+		}
+
+		uf.Union(rp, rq)
+		bottom[uf.Find(rp)] = bp
+	}
+
+	for _, p := range ord {
+
+		added[p] = true
+		bottom[p] = p
+
+		i, j := p/cols, p%cols
+		if i == 0 || i == rows-1 || j == 0 || j == cols-1 {
+			merge(p, outside)
+		}
+
+		if i > 0 && added[p-cols] {
+			merge(p, p-cols)
+		}
+		if i < rows-1 && added[p+cols] {
+			merge(p, p+cols)
+		}
+		if j > 0 && added[p-1] {
+			merge(p, p-1)
+		}
+		if j < cols-1 && added[p+1] {
+			merge(p, p+1)
+		}
+	}
+}
+
+// Pairs returns the lifespans of all holes.
+func (cp *CubicalPersistence) Pairs() []HolePair {
+	return cp.pairs
+}
+
+// BirthDeath returns the hole birth and death times as float64
+// slices, in the same form as Persistence.BirthDeath.
+func (cp *CubicalPersistence) BirthDeath() ([]float64, []float64) {
+
+	var birth, death []float64
+
+	for _, pr := range cp.pairs {
+		birth = append(birth, float64(pr.Birth))
+		death = append(death, float64(pr.Death))
+	}
+
+	return birth, death
+}
+
+type spairs []HolePair
+
+func (a spairs) Len() int      { return len(a) }
+func (a spairs) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a spairs) Less(i, j int) bool {
+	pi := a[i].Death - a[i].Birth
+	pj := a[j].Death - a[j].Birth
+	if pi != pj {
+		return pi < pj
+	}
+	if a[i].Death != a[j].Death {
+		return a[i].Death < a[j].Death
+	}
+	return a[i].Bottom < a[j].Bottom
+}
+
+// Sort orders the holes from the most to the least persistent.
+func (cp *CubicalPersistence) Sort() {
+	sort.Sort(sort.Reverse(spairs(cp.pairs)))
+}
@@ -0,0 +1,105 @@
+package tda
+
+import (
+	"fmt"
+	"testing"
+)
+
+var (
+	cubtests = []struct {
+		img   [][]int
+		pairs []HolePair
+	}{
+		{
+			img: [][]int{
+				{0, 0, 0, 0, 0, 0, 0},
+				{0, 6, 6, 6, 6, 6, 0},
+				{0, 6, 2, 2, 2, 6, 0},
+				{0, 6, 2, 1, 2, 6, 0},
+				{0, 6, 2, 2, 2, 6, 0},
+				{0, 6, 6, 4, 6, 6, 0},
+				{0, 0, 0, 0, 0, 0, 0},
+			},
+			pairs: []HolePair{
+				{Birth: 2, Death: 4, Bottom: 24, Saddle: 38},
+			},
+		},
+		{
+			// The loop is closed through diagonal
+			// connections.
+			img: [][]int{
+				{0, 0, 0, 0, 0},
+				{0, 5, 5, 0, 0},
+				{0, 5, 1, 5, 0},
+				{0, 0, 5, 5, 0},
+				{0, 0, 0, 0, 0},
+			},
+			pairs: []HolePair{
+				{Birth: 5, Death: 5, Bottom: 12, Saddle: 7},
+			},
+		},
+		{
+			// Two holes of different depths, which merge
+			// before escaping to the border.
+			img: [][]int{
+				{0, 0, 0, 0, 0, 0, 0},
+				{0, 9, 9, 9, 9, 9, 0},
+				{0, 9, 1, 5, 3, 9, 0},
+				{0, 9, 9, 9, 9, 9, 0},
+				{0, 0, 0, 0, 0, 0, 0},
+			},
+			pairs: []HolePair{
+				{Birth: 3, Death: 9, Bottom: 16, Saddle: 9},
+				{Birth: 5, Death: 5, Bottom: 18, Saddle: 17},
+			},
+		},
+		{
+			// No holes
+			img: [][]int{
+				{0, 0, 0, 0},
+				{0, 4, 4, 0},
+				{0, 4, 2, 0},
+				{0, 0, 0, 0},
+			},
+		},
+	}
+)
+
+func TestCubicalPersistence(t *testing.T) {
+
+	for jt, test := range cubtests {
+
+		var img []int
+		for _, row := range test.img {
+			img = append(img, row...)
+		}
+
+		cp := NewCubicalPersistence(img, len(test.img))
+		cp.Sort()
+		pairs := cp.Pairs()
+
+		if len(pairs) != len(test.pairs) {
+			fmt.Printf("Found %d holes, expected %d in test %d.\n",
+				len(pairs), len(test.pairs), jt)
+			fmt.Printf("Got:\n%+v\n", pairs)
+			t.Fail()
+			continue
+		}
+
+		for i := range pairs {
+			if pairs[i] != test.pairs[i] {
+				fmt.Printf("Failed test %d, hole %d\n", jt, i)
+				fmt.Printf("Got %+v, expected %+v\n", pairs[i], test.pairs[i])
+				t.Fail()
+			}
+		}
+
+		birth, death := cp.BirthDeath()
+		for i := range birth {
+			if birth[i] != float64(pairs[i].Birth) || death[i] != float64(pairs[i].Death) {
+				fmt.Printf("BirthDeath does not match Pairs in test %d.\n", jt)
+				t.Fail()
+			}
+		}
+	}
+}
@@ -0,0 +1,356 @@
+package tda
+
+import (
+	"image"
+	"sort"
+
+	"github.com/theodesp/unionfind"
+)
+
+// exactState holds the running summary of a connected component
+// during construction of exact persistence trajectories.  The
+// values are stored at the position of the component's root in the
+// union-find structure.
+type exactState struct {
+
+	// The size of the component in pixels
+	size int
+
+	// The maximum intensity of the component
+	max int
+
+	// The smallest linear pixel index in the component
+	first int
+
+	// A bounding box for the component
+	bbox image.Rectangle
+
+	// The index of the trajectory followed by the component, or
+	// -1 if the component was born at the current threshold.
+	traj int
+
+	// The favored child of the component, i.e. the component from
+	// the next higher threshold whose trajectory is continued
+	// into this component.  ctraj is -1 if there is no child.
+	ctraj, cmax, csize, cfirst int
+}
+
+// better returns true if the favored child of a should be preferred
+// over the favored child of b.  The brightest child is preferred,
+// ties are broken by size, and then by position in the image.
+func (a *exactState) better(b *exactState) bool {
+	if b.ctraj == -1 {
+		return a.ctraj != -1
+	}
+	if a.ctraj == -1 {
+		return false
+	}
+	if a.cmax != b.cmax {
+		return a.cmax > b.cmax
+	}
+	if a.csize != b.csize {
+		return a.csize > b.csize
+	}
+	return a.cfirst < b.cfirst
+}
+
+// NewExactPersistence calculates object persistence trajectories
+// for the given image, which must be rectangular with the given
+// number of rows.  Unlike NewPersistence, which thresholds the image
+// at a fixed number of evenly spaced values, the image is
+// thresholded at every distinct pixel intensity, so the birth and
+// death times of the objects are exact.
+//
+// The superlevel sets {img >= t} are constructed in a single pass
+// over the pixels in order of decreasing intensity, merging adjacent
+// pixels with a union-find structure.  When regions merge, the
+// trajectory of the brightest region is continued (the elder rule),
+// consistent with NewPersistence.  Pixels are 8-connected (see
+// NewExactPersistenceOpts for other settings), and pixels on the
+// image border are included in the objects.
+func NewExactPersistence(img []int, rows int) *Persistence {
+	return NewExactPersistenceOpts(img, rows, ExactPersistenceOptions{})
+}
+
+// ExactPersistenceOptions contains optional settings for
+// NewExactPersistenceOpts and NewExactSublevelPersistenceOpts.  The
+// zero value gives the settings used by NewExactPersistence.
+type ExactPersistenceOptions struct {
+
+	// The connectivity (4 or 8) used to define the objects, see
+	// NewLabelConn.  If zero, 8 is used.
+	Conn int
+}
+
+// NewExactPersistenceOpts calculates exact object persistence
+// trajectories as in NewExactPersistence, using the given options.
+func NewExactPersistenceOpts(img []int, rows int, opts ExactPersistenceOptions) *Persistence {
+
+	conn := opts.Conn
+	if conn == 0 {
+		conn = 8
+	}
+	if conn != 4 && conn != 8 {
+		panic("conn must be 4 or 8")
+	}
+
+	cols := len(img) / rows
+	if rows*cols != len(img) {
+		panic("rows is not compatible with img")
+	}
+
+	n := len(img)
+	mn, mx := iminmax(img)
+
+	// Order the pixels by decreasing intensity.
+	ord := make([]int, n)
+	for i := range ord {
+		ord[i] = i
+	}
+	sort.SliceStable(ord, func(i, j int) bool {
+		return img[ord[i]] > img[ord[j]]
+	})
+
+	// The distinct intensities, in increasing order, define the
+	// steps.
+	var levels []int
+	for i := n - 1; i >= 0; i-- {
+		v := img[ord[i]]
+		if len(levels) == 0 || v != levels[len(levels)-1] {
+			levels = append(levels, v)
+		}
+	}
+
+	uf := unionfind.New(n)
+	state := make([]exactState, n)
+	added := make([]bool, n)
+
+	// The trajectories are constructed in order of decreasing
+	// threshold, and are reversed at the end.
+	var traj []Trajectory
+	var roots []int
+
+	// Labels of the objects at the greatest threshold
+	var labels []int
+
+	// The trajectories that do not continue into a merged
+	// component, and the first pixel of the component that they
+	// followed
+	var losers [][2]int
+
+	var branches []Branch
+	var events []Event
+
+	pos := 0
+	for step := len(levels) - 1; step >= 0; step-- {
+
+		t := levels[step]
+
+		// The components from the previous (higher) threshold
+		// are the candidate children.
+		for _, r := range roots {
+			s := &state[r]
+			s.ctraj = s.traj
+			s.cmax = s.max
+			s.csize = s.size
+			s.cfirst = s.first
+		}
+
+		// Add all pixels with intensity t.
+		for ; pos < n && img[ord[pos]] == t; pos++ {
+
+			p := ord[pos]
+			i, j := p/cols, p%cols
+			added[p] = true
+			state[p] = exactState{
+				size:  1,
+				max:   t,
+				first: p,
+				bbox:  image.Rect(j, i, j+1, i+1),
+				traj:  -1,
+				ctraj: -1,
+			}
+			roots = append(roots, p)
+
+			for di := -1; di <= 1; di++ {
+				for dj := -1; dj <= 1; dj++ {
+					if conn == 4 && di != 0 && dj != 0 {
+						continue
+					}
+					i1, j1 := i+di, j+dj
+					if i1 < 0 || i1 >= rows || j1 < 0 || j1 >= cols {
+						continue
+					}
+					q := i1*cols + j1
+					if !added[q] {
+						continue
+					}
+					if lt, lf := exactMerge(uf, state, p, q); lt != -1 {
+						losers = append(losers, [2]int{lt, lf})
+					}
+				}
+			}
+		}
+
+		// Retain only the roots of the current components, in a
+		// deterministic order.
+		j := 0
+		for _, r := range roots {
+			if uf.Find(r) == r {
+				roots[j] = r
+				j++
+			}
+		}
+		roots = roots[0:j]
+		sort.Slice(roots, func(i, j int) bool {
+			return state[roots[i]].first < state[roots[j]].first
+		})
+
+		for k, r := range roots {
+			s := &state[r]
+			if s.ctraj == -1 {
+				traj = append(traj, nil)
+				s.traj = len(traj) - 1
+			} else {
+				s.traj = s.ctraj
+			}
+			ps := Pstate{
+				Label:     k + 1,
+				Size:      s.size,
+				Max:       s.max,
+				Step:      step,
+				Threshold: t,
+				Bbox:      s.bbox,
+			}
+			traj[s.traj] = append(traj[s.traj], ps)
+		}
+
+		// The losing trajectories began at the next higher
+		// threshold, branching off from the trajectory of the
+		// component that they merged into.
+		ev := make(map[int]int)
+		for _, lo := range losers {
+			pt := state[uf.Find(lo[1])].traj
+			for len(branches) < lo[0]+1 {
+				branches = append(branches, Branch{Parent: -1})
+			}
+			branches[lo[0]] = Branch{Parent: pt, Step: step + 1}
+			k, ok := ev[pt]
+			if !ok {
+				k = len(events)
+				ev[pt] = k
+				events = append(events, Event{Step: step + 1, Threshold: levels[step+1], Parent: pt})
+			}
+			events[k].Children = append(events[k].Children, lo[0])
+		}
+		losers = losers[0:0]
+
+		if labels == nil {
+			lab := make(map[int]int)
+			for k, r := range roots {
+				lab[r] = k + 1
+			}
+			labels = make([]int, n)
+			for p := range img {
+				if added[p] {
+					labels[p] = lab[uf.Find(p)]
+				}
+			}
+		}
+	}
+
+	for _, tr := range traj {
+		for i, j := 0, len(tr)-1; i < j; i, j = i+1, j-1 {
+			tr[i], tr[j] = tr[j], tr[i]
+		}
+	}
+
+	for len(branches) < len(traj) {
+		branches = append(branches, Branch{Parent: -1})
+	}
+
+	// Place the events in order of increasing step
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Step < events[j].Step
+	})
+	for _, e := range events {
+		sort.Ints(e.Children)
+	}
+
+	return &Persistence{
+		rows:     rows,
+		cols:     cols,
+		conn:     conn,
+		step:     len(levels) - 1,
+		traj:     traj,
+		img:      img,
+		min:      mn,
+		max:      mx,
+		lbuf2:    labels,
+		branches: branches,
+		events:   events,
+	}
+}
+
+// NewExactSublevelPersistence calculates exact object persistence
+// trajectories for the sublevel sets {img <= t} of the given image.
+// This is equivalent to calling NewExactPersistence on the negated
+// image, and the thresholds and intensities in the returned
+// trajectories are on the negated scale, so that birth times precede
+// death times.
+func NewExactSublevelPersistence(img []int, rows int) *Persistence {
+	return NewExactSublevelPersistenceOpts(img, rows, ExactPersistenceOptions{})
+}
+
+// NewExactSublevelPersistenceOpts calculates exact object persistence
+// trajectories for the sublevel sets as in
+// NewExactSublevelPersistence, using the given options.
+func NewExactSublevelPersistenceOpts(img []int, rows int, opts ExactPersistenceOptions) *Persistence {
+
+	nimg := make([]int, len(img))
+	for i, v := range img {
+		nimg[i] = -v
+	}
+
+	return NewExactPersistenceOpts(nimg, rows, opts)
+}
+
+// exactMerge joins the components containing pixels p and q,
+// combining their summary states.  If both components have a favored
+// child, the trajectory and first pixel of the child that is not
+// favored in the merged component are returned, otherwise the
+// returned trajectory is -1.
+func exactMerge(uf *unionfind.UnionFind, state []exactState, p, q int) (int, int) {
+
+	rp := uf.Find(p)
+	rq := uf.Find(q)
+	if rp == rq {
+		return -1, -1
+	}
+
+	sp := state[rp]
+	sq := state[rq]
+
+	uf.Union(rp, rq)
+	r := uf.Find(rp)
+
+	s := sp
+	lt, lf := sq.ctraj, sq.cfirst
+	if sq.better(&sp) {
+		s.ctraj, s.cmax, s.csize, s.cfirst = sq.ctraj, sq.cmax, sq.csize, sq.cfirst
+		lt, lf = sp.ctraj, sp.cfirst
+	}
+	s.size = sp.size + sq.size
+	if sq.max > s.max {
+		s.max = sq.max
+	}
+	if sq.first < s.first {
+		s.first = sq.first
+	}
+	s.bbox = sp.bbox.Union(sq.bbox)
+	s.traj = -1
+
+	state[r] = s
+
+	return lt, lf
+}
@@ -0,0 +1,256 @@
+package tda
+
+import (
+	"fmt"
+	"image"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+var (
+	exptests = []struct {
+		img   [][]int
+		traj  [][]Pstate
+		birth []float64
+		death []float64
+	}{
+		{
+			img: [][]int{
+				{0, 0, 0, 0, 0, 0, 0, 0},
+				{0, 3, 3, 1, 3, 3, 3, 0},
+				{0, 3, 3, 1, 3, 3, 3, 0},
+				{0, 3, 3, 1, 3, 3, 3, 0},
+				{0, 3, 3, 1, 2, 2, 2, 0},
+				{0, 3, 3, 1, 3, 3, 3, 0},
+				{0, 3, 3, 1, 3, 3, 3, 0},
+				{0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			traj: [][]Pstate{
+				{
+					{Label: 1, Size: 64, Max: 3, Step: 0, Threshold: 0, Bbox: image.Rect(0, 0, 8, 8)},
+					{Label: 1, Size: 36, Max: 3, Step: 1, Threshold: 1, Bbox: image.Rect(1, 1, 7, 7)},
+					{Label: 2, Size: 18, Max: 3, Step: 2, Threshold: 2, Bbox: image.Rect(4, 1, 7, 7)},
+					{Label: 2, Size: 9, Max: 3, Step: 3, Threshold: 3, Bbox: image.Rect(4, 1, 7, 4)},
+				},
+				{
+					{Label: 1, Size: 12, Max: 3, Step: 2, Threshold: 2, Bbox: image.Rect(1, 1, 3, 7)},
+					{Label: 1, Size: 12, Max: 3, Step: 3, Threshold: 3, Bbox: image.Rect(1, 1, 3, 7)},
+				},
+				{
+					{Label: 3, Size: 6, Max: 3, Step: 3, Threshold: 3, Bbox: image.Rect(4, 5, 7, 7)},
+				},
+			},
+			birth: []float64{0, 2, 3},
+			death: []float64{3, 3, 3},
+		},
+		{
+			img: [][]int{
+				{0, 0, 0, 0, 0, 0, 0, 0},
+				{0, 6, 1, 6, 1, 7, 6, 0},
+				{0, 6, 1, 6, 1, 7, 6, 0},
+				{0, 6, 1, 5, 1, 1, 6, 0},
+				{0, 6, 1, 6, 1, 1, 4, 0},
+				{0, 6, 1, 6, 1, 1, 6, 0},
+				{0, 5, 5, 5, 5, 5, 5, 0},
+				{0, 0, 0, 0, 0, 0, 0, 0},
+			},
+			traj: [][]Pstate{
+				{
+					{Label: 1, Size: 64, Max: 7, Step: 0, Threshold: 0, Bbox: image.Rect(0, 0, 8, 8)},
+					{Label: 1, Size: 36, Max: 7, Step: 1, Threshold: 1, Bbox: image.Rect(1, 1, 7, 7)},
+					{Label: 1, Size: 23, Max: 7, Step: 2, Threshold: 4, Bbox: image.Rect(1, 1, 7, 7)},
+					{Label: 2, Size: 5, Max: 7, Step: 3, Threshold: 5, Bbox: image.Rect(5, 1, 7, 4)},
+					{Label: 3, Size: 5, Max: 7, Step: 4, Threshold: 6, Bbox: image.Rect(5, 1, 7, 4)},
+					{Label: 1, Size: 2, Max: 7, Step: 5, Threshold: 7, Bbox: image.Rect(5, 1, 6, 3)},
+				},
+				{
+					{Label: 1, Size: 17, Max: 6, Step: 3, Threshold: 5, Bbox: image.Rect(1, 1, 7, 7)},
+					{Label: 1, Size: 5, Max: 6, Step: 4, Threshold: 6, Bbox: image.Rect(1, 1, 2, 6)},
+				},
+				{
+					{Label: 4, Size: 2, Max: 6, Step: 4, Threshold: 6, Bbox: image.Rect(3, 4, 4, 6)},
+				},
+				{
+					{Label: 2, Size: 2, Max: 6, Step: 4, Threshold: 6, Bbox: image.Rect(3, 1, 4, 3)},
+				},
+				{
+					{Label: 5, Size: 1, Max: 6, Step: 4, Threshold: 6, Bbox: image.Rect(6, 5, 7, 6)},
+				},
+			},
+			birth: []float64{0, 5, 6, 6, 6},
+			death: []float64{7, 6, 6, 6, 6},
+		},
+	}
+)
+
+func TestExactPersistence(t *testing.T) {
+
+	for jt, test := range exptests {
+
+		var img []int
+		for _, row := range test.img {
+			img = append(img, row...)
+		}
+
+		ps := NewExactPersistence(img, len(test.img))
+		ps.Sort()
+		traj := ps.Trajectories()
+
+		if len(traj) != len(test.traj) {
+			fmt.Printf("Found %d trajectories, expected %d in test %d.\n",
+				len(traj), len(test.traj), jt)
+			fmt.Printf("Got:\n%+v\n", traj)
+			t.Fail()
+			continue
+		}
+
+		for i := range traj {
+			if !compareTraj(traj[i], test.traj[i]) {
+				fmt.Printf("Failed test %d, trajectory %d\nGot:\n", jt, i)
+				fmt.Printf("%+v\n", traj[i])
+				fmt.Printf("Expected:\n%+v\n", test.traj[i])
+				t.Fail()
+			}
+		}
+
+		birth, death := ps.BirthDeath()
+		if !floats.Equal(birth, test.birth) || !floats.Equal(death, test.death) {
+			fmt.Printf("Birth/death times do not match in test %d.\n", jt)
+			fmt.Printf("Got %v, %v\nExpected %v, %v\n", birth, death, test.birth, test.death)
+			t.Fail()
+		}
+	}
+}
+
+// Negating the image should give the same trajectories for the
+// sublevel sets as are obtained for the superlevel sets.
+func TestExactSublevelPersistence(t *testing.T) {
+
+	for jt, test := range exptests {
+
+		var img []int
+		for _, row := range test.img {
+			for _, v := range row {
+				img = append(img, -v)
+			}
+		}
+
+		ps := NewExactSublevelPersistence(img, len(test.img))
+		ps.Sort()
+		traj := ps.Trajectories()
+
+		if len(traj) != len(test.traj) {
+			fmt.Printf("Found %d trajectories, expected %d in test %d.\n",
+				len(traj), len(test.traj), jt)
+			t.Fail()
+			continue
+		}
+
+		for i := range traj {
+			if !compareTraj(traj[i], test.traj[i]) {
+				fmt.Printf("Failed sublevel test %d, trajectory %d\n", jt, i)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestExactPersistenceBranches(t *testing.T) {
+
+	expected := []struct {
+		branches []Branch
+		events   []Event
+	}{
+		{
+			branches: []Branch{{Parent: -1, Step: 0}, {Parent: 0, Step: 2}, {Parent: 0, Step: 3}},
+			events: []Event{
+				{Step: 2, Threshold: 2, Parent: 0, Children: []int{1}},
+				{Step: 3, Threshold: 3, Parent: 0, Children: []int{2}},
+			},
+		},
+		{
+			branches: []Branch{
+				{Parent: -1, Step: 0},
+				{Parent: 0, Step: 3},
+				{Parent: 1, Step: 4},
+				{Parent: 1, Step: 4},
+				{Parent: 1, Step: 4},
+			},
+			events: []Event{
+				{Step: 3, Threshold: 5, Parent: 0, Children: []int{1}},
+				{Step: 4, Threshold: 6, Parent: 1, Children: []int{2, 3, 4}},
+			},
+		},
+	}
+
+	for jt, test := range exptests {
+
+		var img []int
+		for _, row := range test.img {
+			img = append(img, row...)
+		}
+
+		ps := NewExactPersistence(img, len(test.img))
+		ps.Sort()
+
+		if !compareBranches(ps.Branches(), expected[jt].branches) {
+			fmt.Printf("Branches do not match in test %d\n", jt)
+			fmt.Printf("Got %+v\nExpected %+v\n", ps.Branches(), expected[jt].branches)
+			t.Fail()
+		}
+
+		if !compareEvents(ps.Events(), expected[jt].events) {
+			fmt.Printf("Events do not match in test %d\n", jt)
+			fmt.Printf("Got %+v\nExpected %+v\n", ps.Events(), expected[jt].events)
+			t.Fail()
+		}
+	}
+}
+
+// Two bright pixels that touch only at a corner are one object with
+// 8-connectivity and two objects with 4-connectivity.
+func TestExactPersistenceConn(t *testing.T) {
+
+	img := []int{
+		0, 0, 0, 0,
+		0, 5, 0, 0,
+		0, 0, 5, 0,
+		0, 0, 0, 0,
+	}
+
+	for _, tr := range []struct {
+		conn, ntraj int
+	}{
+		{0, 1},
+		{8, 1},
+		{4, 2},
+	} {
+		ps := NewExactPersistenceOpts(img, 4, ExactPersistenceOptions{Conn: tr.conn})
+		if n := len(ps.Trajectories()); n != tr.ntraj {
+			fmt.Printf("Conn %d: found %d trajectories, expected %d\n", tr.conn, n, tr.ntraj)
+			t.Fail()
+		}
+
+		// The pixels of each object are recovered with the same
+		// connectivity.
+		ps.Sort()
+		for k := range ps.Trajectories() {
+			if pix := ps.Pixels(k, 0); len(pix) != ps.Trajectories()[k][0].Size {
+				fmt.Printf("Conn %d: trajectory %d has %d pixels\n", tr.conn, k, len(pix))
+				t.Fail()
+			}
+		}
+
+		// The sublevel sets of the negated image are the same.
+		nimg := make([]int, len(img))
+		for i, v := range img {
+			nimg[i] = -v
+		}
+		ps = NewExactSublevelPersistenceOpts(nimg, 4, ExactPersistenceOptions{Conn: tr.conn})
+		if n := len(ps.Trajectories()); n != tr.ntraj {
+			fmt.Printf("Conn %d: found %d sublevel trajectories, expected %d\n", tr.conn, n, tr.ntraj)
+			t.Fail()
+		}
+	}
+}
@@ -45,22 +45,19 @@ func main() {
 		}
 		fmt.Printf("    %d pixels have value '1'\n", n1)
 
-		lab := lbl.Labels()
-
 		// Get the mean component size
 		meanSize := float64(n1) / float64(lbl.NumComponents())
 		fmt.Printf("    mean component size is %f\n", meanSize)
 
-		// Get the distribution of sizes.
-		sizes := make([]int, lbl.NumComponents())
-		for _, v := range lab {
-			sizes[v]++
+		// Get the range of component sizes, skipping the
+		// background.
+		sizes := lbl.Sizes(nil)
+		if len(sizes) < 2 {
+			continue
 		}
-
-		// Get the range of component sizes
-		mn := sizes[0]
-		mx := sizes[0]
-		for _, v := range lab {
+		mn := sizes[1]
+		mx := sizes[1]
+		for _, v := range sizes[1:] {
 			if v < mn {
 				mn = v
 			}
@@ -69,5 +66,16 @@ func main() {
 			}
 		}
 		fmt.Printf("    Components range from %d to %d pixels in size.\n", mn, mx)
+
+		// Describe the shape of the largest component.
+		big := 1
+		for k, v := range sizes[1:] {
+			if v > sizes[big] {
+				big = k + 1
+			}
+		}
+		p := lbl.RegionProps(img)[big]
+		fmt.Printf("    Largest component has centroid (%.1f, %.1f), eccentricity %.3f and %d holes.\n",
+			p.Centroid[0], p.Centroid[1], p.Eccentricity, 1-p.Euler)
 	}
 }
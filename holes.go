@@ -0,0 +1,69 @@
+package tda
+
+// FillHoles identifies the holes in each labeled component, i.e. the
+// connected regions of background that are not connected to the
+// border of the image.  The number of holes in the component with
+// label k is held in position k of the first returned slice, so the
+// Euler number of the component is one minus this count.  The second
+// returned value is a copy of the mask in which all holes are filled
+// with 1's, which can be passed to NewLabel again.
+//
+// The background is labeled using 4-connectivity if the components
+// are 8-connected, and using 8-connectivity if the components are
+// 4-connected.  A hole is assigned to the component containing the
+// pixel directly above the first pixel of the hole in raster order,
+// which is the innermost component enclosing the hole.  Components
+// nested inside a hole are retained in the filled mask.
+func (la *Label) FillHoles() ([]int, []uint8) {
+
+	r := la.rows
+	c := la.cols
+
+	// The complement of the mask
+	bg := make([]uint8, r*c)
+	for i, v := range la.mask {
+		if v == 0 {
+			bg[i] = 1
+		}
+	}
+
+	bconn := 4
+	if la.conn == 4 {
+		bconn = 8
+	}
+	bl := NewLabelPreserve(bg, r, bconn, nil)
+	blab := bl.Labels()
+	nb := bl.NumComponents()
+
+	// Background components that touch the border are not holes.
+	border := make([]bool, nb)
+	for j := 0; j < c; j++ {
+		border[blab[j]] = true
+		border[blab[(r-1)*c+j]] = true
+	}
+	for i := 0; i < r; i++ {
+		border[blab[i*c]] = true
+		border[blab[i*c+c-1]] = true
+	}
+
+	holes := make([]int, la.ncomp)
+	seen := make([]bool, nb)
+	filled := make([]uint8, r*c)
+	copy(filled, la.mask)
+
+	for i, b := range blab {
+		if b == 0 || border[b] {
+			continue
+		}
+		filled[i] = 1
+		if !seen[b] {
+			// This is the first pixel of the hole, and is
+			// not in the first row since the hole does not
+			// touch the border.
+			seen[b] = true
+			holes[la.labels[i-c]]++
+		}
+	}
+
+	return holes, filled
+}
@@ -0,0 +1,125 @@
+package tda
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFillHoles(t *testing.T) {
+
+	for jt, tr := range []struct {
+		img    []string
+		conn   int
+		holes  []int
+		filled []string
+	}{
+		{
+			// A component with two holes, one of which
+			// contains another component with one hole.
+			img: []string{
+				"000000000000",
+				"011111111110",
+				"010000000010",
+				"010111110010",
+				"010101010010",
+				"010111110010",
+				"010000000010",
+				"011111111110",
+				"010000100000",
+				"011111100000",
+				"000000000000",
+			},
+			conn:  8,
+			holes: []int{0, 2, 2},
+			filled: []string{
+				"000000000000",
+				"011111111110",
+				"011111111110",
+				"011111111110",
+				"011111111110",
+				"011111111110",
+				"011111111110",
+				"011111111110",
+				"011111100000",
+				"011111100000",
+				"000000000000",
+			},
+		},
+		{
+			// The hole is only enclosed with 8-connectivity.
+			img: []string{
+				"00000",
+				"00100",
+				"01010",
+				"00100",
+				"00000",
+			},
+			conn:  8,
+			holes: []int{0, 1},
+			filled: []string{
+				"00000",
+				"00100",
+				"01110",
+				"00100",
+				"00000",
+			},
+		},
+		{
+			img: []string{
+				"00000",
+				"00100",
+				"01010",
+				"00100",
+				"00000",
+			},
+			conn:  4,
+			holes: []int{0, 0, 0, 0, 0},
+			filled: []string{
+				"00000",
+				"00100",
+				"01010",
+				"00100",
+				"00000",
+			},
+		},
+	} {
+		mask := unpack(tr.img)
+		la := NewLabelPreserve(mask, len(tr.img), tr.conn, nil)
+		holes, filled := la.FillHoles()
+
+		if !compareSizes(holes, tr.holes) {
+			fmt.Printf("Hole counts do not match in test %d\n", jt)
+			fmt.Printf("Got %v, expected %v\n", holes, tr.holes)
+			t.Fail()
+		}
+
+		efilled := unpack(tr.filled)
+		for i := range filled {
+			if filled[i] != efilled[i] {
+				fmt.Printf("Filled mask does not match in test %d\nGot:\n", jt)
+				uprint(filled, len(tr.img))
+				t.Fail()
+				break
+			}
+		}
+
+		// The Euler numbers should agree with RegionProps
+		props := la.RegionProps(nil)
+		for k := 1; k < len(props); k++ {
+			if props[k].Euler != 1-holes[k] {
+				fmt.Printf("Euler number %d does not agree with %d holes in test %d\n",
+					props[k].Euler, holes[k], jt)
+				t.Fail()
+			}
+		}
+
+		// The filled mask has no holes
+		holes2, _ := NewLabelPreserve(filled, len(tr.img), tr.conn, nil).FillHoles()
+		for _, h := range holes2 {
+			if h != 0 {
+				fmt.Printf("Filled mask has holes in test %d\n", jt)
+				t.Fail()
+			}
+		}
+	}
+}
@@ -13,6 +13,13 @@ type Label struct {
 	rows int
 	cols int
 
+	// The connectivity, 4 or 8
+	conn int
+
+	// If true, the mask is not modified and the image border is
+	// labeled like the rest of the image
+	preserve bool
+
 	// The binary image (coded 0/1) used to define the connected
 	// regions.
 	mask []uint8
@@ -33,23 +40,60 @@ type Label struct {
 // (mask), which is rectangular with the given number of rows.  buf is
 // an optional memory buffer having the same length as mask.  Use the
 // methods of the returned Label value to obtain information about the
-// labels.
+// labels.  Pixels that touch at a side or a corner belong to the
+// same component (8-connectivity), use NewLabelConn to select a
+// different connectivity.
+//
+// NewLabel sets the pixels in the first and last rows and columns of
+// mask to zero before labeling, so objects touching the image border
+// lose these pixels.  Use NewLabelPreserve to label the border pixels
+// without modifying mask.
 //
 // The algorithm implemented here is the run-based algorithm of He et
 // al. (2008), IEEE Transactions on Image Processing, 17:5.
 // https://ieeexplore.ieee.org/stamp/stamp.jsp?tp=&arnumber=4472694
 func NewLabel(mask []uint8, rows int, buf []int) *Label {
+	return NewLabelConn(mask, rows, 8, buf)
+}
+
+// NewLabelConn finds the connected components of a given binary image
+// using the given connectivity.  If conn is 4, pixels belong to the
+// same component only if they are connected through a sequence of
+// pixels that share a side.  If conn is 8, pixels that share only a
+// corner are also considered to be adjacent.  The remaining arguments
+// are as in NewLabel.
+func NewLabelConn(mask []uint8, rows, conn int, buf []int) *Label {
+	return newLabel(mask, rows, conn, false, buf)
+}
+
+// NewLabelPreserve finds the connected components of a given binary
+// image using the given connectivity (4 or 8, see NewLabelConn).
+// Unlike NewLabel and NewLabelConn, the mask is not modified, and
+// pixels outside of the image are treated as background, so that
+// objects touching the image border are labeled in their entirety.
+// The remaining arguments are as in NewLabel.
+func NewLabelPreserve(mask []uint8, rows, conn int, buf []int) *Label {
+	return newLabel(mask, rows, conn, true, buf)
+}
+
+func newLabel(mask []uint8, rows, conn int, preserve bool, buf []int) *Label {
 
 	cols := len(mask) / rows
 	if rows*cols != len(mask) {
 		panic("Invalid number of rows")
 	}
 
+	if conn != 4 && conn != 8 {
+		panic("conn must be 4 or 8")
+	}
+
 	la := &Label{
-		rows:   rows,
-		cols:   cols,
-		mask:   mask,
-		labels: buf,
+		rows:     rows,
+		cols:     cols,
+		conn:     conn,
+		preserve: preserve,
+		mask:     mask,
+		labels:   buf,
 	}
 
 	la.init()
@@ -63,18 +107,23 @@ func (la *Label) init() {
 	r := la.rows
 	c := la.cols
 
-	la.uf = unionfind.New(r * c)
+	// There is at most one provisional label per pixel, and label
+	// 0 is reserved for the background.
+	la.uf = unionfind.New(r*c + 1)
 
-	// Blank out the first and last row
-	for j := 0; j < c; j++ {
-		la.mask[j] = 0
-		la.mask[(r-1)*c+j] = 0
-	}
+	if !la.preserve {
 
-	// Blank out the first and last column
-	for i := 0; i < r; i++ {
-		la.mask[i*c] = 0
-		la.mask[i*c+c-1] = 0
+		// Blank out the first and last row
+		for j := 0; j < c; j++ {
+			la.mask[j] = 0
+			la.mask[(r-1)*c+j] = 0
+		}
+
+		// Blank out the first and last column
+		for i := 0; i < r; i++ {
+			la.mask[i*c] = 0
+			la.mask[i*c+c-1] = 0
+		}
 	}
 
 	if cap(la.labels) < r*c {
@@ -131,7 +180,7 @@ func (la *Label) labelPass1() {
 	var j1, j2, k1, k2 int
 	var vu int = 1
 
-	for i := 1; i < la.rows; i++ {
+	for i := 0; i < la.rows; i++ {
 
 		j1 = 0
 		for {
@@ -140,14 +189,33 @@ func (la *Label) labelPass1() {
 				break
 			}
 
+			if i == 0 {
+				// Starting a new region in the first row
+				for j := j1; j < j2; j++ {
+					la.labels[j] = vu
+				}
+				vu++
+				j1 = j2
+				continue
+			}
+
 			// Find all runs in the previous row that
-			// overlap with the current run
+			// overlap with the current run.  With
+			// 8-connectivity, runs that touch the current
+			// run diagonally also overlap.
 			k1 = j1 - 1
+			kmax := j2
+			if la.conn == 4 || k1 < 0 {
+				k1 = j1
+			}
+			if la.conn == 4 {
+				kmax = j2 - 1
+			}
 			first := true
 			var vf int
 			for k1 < c {
 				k1, k2 = la.nextRun(i-1, k1, c)
-				if k1 == -1 || k1 > j2 {
+				if k1 == -1 || k1 > kmax {
 					break
 				}
 
@@ -193,32 +261,30 @@ func (la *Label) labelPass2() {
 	}
 }
 
-// Renumber the components so there are no gaps.
+// Renumber the components so there are no gaps, in the order in
+// which the components are first encountered in raster order.  The
+// background label is retained even if there are no background
+// pixels.
 func (la *Label) labelPass3() {
 
-	cnt := make([]int, 0, 1000)
-	for _, v := range la.labels {
-		for len(cnt) < v+1 {
-			cnt = append(cnt, 0)
-		}
-		cnt[v]++
-	}
+	// mp defines a mapping from old labels to new labels, -1
+	// indicates that an old label has not yet been encountered.
+	mp := make([]int, 0, 1000)
+	mp = append(mp, 0)
+	ncomp := 1
 
-	// mp defines a mapping from old labels to new labels
-	ncomp := 0
-	mp := make([]int, len(cnt))
-	for j := range cnt {
-		if cnt[j] > 0 {
-			mp[j] = ncomp
+	for i, v := range la.labels {
+		for len(mp) < v+1 {
+			mp = append(mp, -1)
+		}
+		if mp[v] == -1 {
+			mp[v] = ncomp
 			ncomp++
 		}
+		la.labels[i] = mp[v]
 	}
-	la.ncomp = ncomp
 
-	// Update the labels
-	for i := range la.labels {
-		la.labels[i] = mp[la.labels[i]]
-	}
+	la.ncomp = ncomp
 }
 
 // Sizes returns the sizes (number of pixels) in every labeled
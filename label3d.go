@@ -0,0 +1,272 @@
+package tda
+
+import (
+	"github.com/theodesp/unionfind"
+)
+
+// Point3 is a voxel position in a volume.  X is the column, Y is the
+// row, and Z is the slice.
+type Point3 struct {
+	X, Y, Z int
+}
+
+// Box3 is a bounding box in a volume.  The box contains the voxels p
+// with Min.X <= p.X < Max.X, Min.Y <= p.Y < Max.Y, and Min.Z <= p.Z
+// < Max.Z.
+type Box3 struct {
+	Min, Max Point3
+}
+
+// Label3D finds the connected components in a binary volume.
+type Label3D struct {
+
+	// The dimensions of the volume that is being processed
+	rows   int
+	cols   int
+	slices int
+
+	// The connectivity, 6, 18 or 26
+	conn int
+
+	// The binary volume (coded 0/1) used to define the connected
+	// regions.
+	mask []uint8
+
+	// The labels of the connected regions
+	labels []int
+
+	// The number of components, including the background.  The
+	// greatest component label is ncomp-1.
+	ncomp int
+}
+
+// NewLabel3D finds the connected components of a given binary volume
+// (mask), which is a stack of rectangular slices, each having the
+// given number of rows and columns.  The voxels are stored with the
+// column index varying fastest and the slice index varying slowest.
+// The connectivity conn must be 6 (voxels sharing a face are
+// adjacent), 18 (voxels sharing a face or an edge are adjacent) or 26
+// (voxels sharing a face, edge or corner are adjacent).  buf is an
+// optional memory buffer having the same length as mask.
+//
+// The mask is not modified, and voxels on the boundary of the volume
+// are labeled in the same way as interior voxels.
+func NewLabel3D(mask []uint8, rows, cols, conn int, buf []int) *Label3D {
+
+	slices := len(mask) / (rows * cols)
+	if rows*cols*slices != len(mask) {
+		panic("Invalid number of rows or columns")
+	}
+
+	if conn != 6 && conn != 18 && conn != 26 {
+		panic("conn must be 6, 18 or 26")
+	}
+
+	la := &Label3D{
+		rows:   rows,
+		cols:   cols,
+		slices: slices,
+		conn:   conn,
+		mask:   mask,
+		labels: buf,
+	}
+
+	la.label()
+
+	return la
+}
+
+// offsets3 returns the offsets (dx, dy, dz) to the neighbors of a
+// voxel that precede it in raster order, under the given
+// connectivity.
+func offsets3(conn int) [][3]int {
+
+	// The maximum number of non-zero coordinates in an offset
+	var mx int
+	switch conn {
+	case 6:
+		mx = 1
+	case 18:
+		mx = 2
+	case 26:
+		mx = 3
+	}
+
+	var off [][3]int
+	for dz := -1; dz <= 0; dz++ {
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if dz == 0 && (dy > 0 || (dy == 0 && dx >= 0)) {
+					continue
+				}
+				nz := 0
+				for _, d := range []int{dx, dy, dz} {
+					if d != 0 {
+						nz++
+					}
+				}
+				if nz <= mx {
+					off = append(off, [3]int{dx, dy, dz})
+				}
+			}
+		}
+	}
+
+	return off
+}
+
+func (la *Label3D) label() {
+
+	r, c, s := la.rows, la.cols, la.slices
+	n := r * c * s
+
+	if cap(la.labels) < n {
+		la.labels = make([]int, n)
+	} else {
+		la.labels = la.labels[0:n]
+	}
+
+	uf := unionfind.New(n)
+	off := offsets3(la.conn)
+
+	// Merge each foreground voxel with its foreground neighbors
+	// that precede it in raster order.
+	p := 0
+	for z := 0; z < s; z++ {
+		for y := 0; y < r; y++ {
+			for x := 0; x < c; x++ {
+				if la.mask[p] != 0 {
+					for _, o := range off {
+						x1, y1, z1 := x+o[0], y+o[1], z+o[2]
+						if x1 < 0 || x1 >= c || y1 < 0 || y1 >= r || z1 < 0 {
+							continue
+						}
+						q := (z1*r+y1)*c + x1
+						if la.mask[q] != 0 {
+							uf.Union(p, q)
+						}
+					}
+				}
+				p++
+			}
+		}
+	}
+
+	// Number the components in the order of their first voxel.
+	for p := range la.labels {
+		la.labels[p] = 0
+	}
+	la.ncomp = 1
+	root := make(map[int]int)
+	for p := range la.mask {
+		if la.mask[p] == 0 {
+			continue
+		}
+		q := uf.Find(p)
+		l, ok := root[q]
+		if !ok {
+			l = la.ncomp
+			root[q] = l
+			la.ncomp++
+		}
+		la.labels[p] = l
+	}
+}
+
+// NumComponents returns the number of components, including the
+// background component.  The maximum component label is one less than
+// the number of components.
+func (la *Label3D) NumComponents() int {
+	return la.ncomp
+}
+
+// Sizes returns the sizes (number of voxels) in every labeled
+// component of the volume.  The size of the component with label k
+// is held in position k of the returned slice.  The provided buffer
+// will be used if large enough.
+func (la *Label3D) Sizes(buf []int) []int {
+
+	if cap(buf) < la.ncomp {
+		buf = make([]int, la.ncomp)
+	} else {
+		buf = buf[0:la.ncomp]
+		for i := range buf {
+			buf[i] = 0
+		}
+	}
+
+	for _, v := range la.labels {
+		buf[v]++
+	}
+
+	return buf
+}
+
+// Bboxes returns the bounding boxes for every labeled component.  The
+// bounding box for the component with label k is held in position k
+// of the returned slice.  The provided slice is used if large enough.
+func (la *Label3D) Bboxes(buf []Box3) []Box3 {
+
+	if cap(buf) < la.ncomp {
+		buf = make([]Box3, la.ncomp)
+	} else {
+		buf = buf[0:la.ncomp]
+	}
+	bf := make([]bool, la.ncomp)
+
+	rc := la.rows * la.cols
+	for i, v := range la.labels {
+		pt := Point3{X: i % la.cols, Y: (i % rc) / la.cols, Z: i / rc}
+		if !bf[v] {
+			buf[v] = Box3{Min: pt, Max: Point3{pt.X + 1, pt.Y + 1, pt.Z + 1}}
+			bf[v] = true
+			continue
+		}
+		b := &buf[v]
+		if pt.X < b.Min.X {
+			b.Min.X = pt.X
+		}
+		if pt.X+1 > b.Max.X {
+			b.Max.X = pt.X + 1
+		}
+		if pt.Y < b.Min.Y {
+			b.Min.Y = pt.Y
+		}
+		if pt.Y+1 > b.Max.Y {
+			b.Max.Y = pt.Y + 1
+		}
+		if pt.Z < b.Min.Z {
+			b.Min.Z = pt.Z
+		}
+		if pt.Z+1 > b.Max.Z {
+			b.Max.Z = pt.Z + 1
+		}
+	}
+
+	return buf
+}
+
+// Labels returns the component labels.
+func (la *Label3D) Labels() []int {
+	return la.labels
+}
+
+// Mask returns the volume that is being labeled.
+func (la *Label3D) Mask() []uint8 {
+	return la.mask
+}
+
+// Rows returns the number of rows in each slice of the volume.
+func (la *Label3D) Rows() int {
+	return la.rows
+}
+
+// Cols returns the number of columns in each slice of the volume.
+func (la *Label3D) Cols() int {
+	return la.cols
+}
+
+// Slices returns the number of slices in the volume.
+func (la *Label3D) Slices() int {
+	return la.slices
+}
@@ -0,0 +1,94 @@
+package tda
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestLabel3D(t *testing.T) {
+
+	// A 3x3x3 volume with four foreground voxels.  The first two
+	// share an edge, the second and third share a corner, and the
+	// fourth is isolated.
+	mask := make([]uint8, 27)
+	for _, p := range []int{0, 4, 17, 24} {
+		mask[p] = 1
+	}
+	orig := make([]uint8, len(mask))
+	copy(orig, mask)
+
+	for jt, tr := range []struct {
+		conn   int
+		ncomp  int
+		labels map[int]int
+		sizes  []int
+		bboxes []Box3
+	}{
+		{
+			conn:   6,
+			ncomp:  5,
+			labels: map[int]int{0: 1, 4: 2, 17: 3, 24: 4},
+			sizes:  []int{23, 1, 1, 1, 1},
+		},
+		{
+			conn:   18,
+			ncomp:  4,
+			labels: map[int]int{0: 1, 4: 1, 17: 2, 24: 3},
+			sizes:  []int{23, 2, 1, 1},
+		},
+		{
+			conn:   26,
+			ncomp:  3,
+			labels: map[int]int{0: 1, 4: 1, 17: 1, 24: 2},
+			sizes:  []int{23, 3, 1},
+			bboxes: []Box3{
+				{Min: Point3{0, 0, 0}, Max: Point3{3, 3, 3}},
+				{Min: Point3{0, 0, 0}, Max: Point3{3, 3, 2}},
+				{Min: Point3{0, 2, 2}, Max: Point3{1, 3, 3}},
+			},
+		},
+	} {
+		la := NewLabel3D(mask, 3, 3, tr.conn, nil)
+
+		if la.NumComponents() != tr.ncomp {
+			fmt.Printf("NumComponents is %d, should be %d in test %d\n",
+				la.NumComponents(), tr.ncomp, jt)
+			t.Fail()
+		}
+
+		lab := la.Labels()
+		for p, v := range lab {
+			if lab[p] != tr.labels[p] {
+				fmt.Printf("Voxel %d has label %d, expected %d in test %d\n",
+					p, v, tr.labels[p], jt)
+				t.Fail()
+			}
+		}
+
+		if !compareSizes(la.Sizes(nil), tr.sizes) {
+			fmt.Printf("Sizes do not match for test %d\n", jt)
+			fmt.Printf("Got:\n%v\n", la.Sizes(nil))
+			fmt.Printf("Expected:\n%v\n", tr.sizes)
+			t.Fail()
+		}
+
+		if tr.bboxes != nil {
+			bb := la.Bboxes(nil)
+			for k := range bb {
+				if bb[k] != tr.bboxes[k] {
+					fmt.Printf("Bounding boxes do not match in test %d.\n", jt)
+					fmt.Printf("Got %v, expected %v.\n", bb, tr.bboxes)
+					t.Fail()
+					break
+				}
+			}
+		}
+	}
+
+	for p := range mask {
+		if mask[p] != orig[p] {
+			fmt.Printf("Mask was modified\n")
+			t.Fail()
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package tda
+
+import (
+	"sync"
+
+	"github.com/theodesp/unionfind"
+)
+
+// NewLabelParallel finds the connected components of a given binary
+// image using several goroutines.  The rows of the image are split
+// into strips, one per worker, which are labeled concurrently.  The
+// labels of components that cross the boundaries between strips are
+// then merged.  The results are identical to those of
+// NewLabelPreserve, and the remaining arguments are as in
+// NewLabelPreserve.  If workers is less than 1, one worker is used.
+func NewLabelParallel(mask []uint8, rows, conn, workers int, buf []int) *Label {
+
+	cols := len(mask) / rows
+	if rows*cols != len(mask) {
+		panic("Invalid number of rows")
+	}
+
+	if conn != 4 && conn != 8 {
+		panic("conn must be 4 or 8")
+	}
+
+	if workers > rows {
+		workers = rows
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	n := rows * cols
+	if cap(buf) < n {
+		buf = make([]int, n)
+	} else {
+		buf = buf[0:n]
+	}
+
+	// The first row of each strip, with a final sentinel
+	start := make([]int, workers+1)
+	for s := range start {
+		start[s] = s * rows / workers
+	}
+
+	// Label each strip.  Each strip is labeled with NewLabelPreserve,
+	// so its labels are numbered in order of first appearance.
+	strips := make([]*Label, workers)
+	var wg sync.WaitGroup
+	for s := 0; s < workers; s++ {
+		wg.Add(1)
+		go func(s int) {
+			defer wg.Done()
+			i0, i1 := start[s]*cols, start[s+1]*cols
+			strips[s] = NewLabelPreserve(mask[i0:i1], start[s+1]-start[s], conn, buf[i0:i1:i1])
+		}(s)
+	}
+	wg.Wait()
+
+	// The strip labels are mapped into a common range of labels,
+	// the non-background labels of strip s begin at offset[s]+1.
+	offset := make([]int, workers+1)
+	for s := 0; s < workers; s++ {
+		offset[s+1] = offset[s] + strips[s].NumComponents() - 1
+	}
+
+	// Merge the labels along the boundaries between strips.
+	uf := unionfind.New(offset[workers] + 1)
+	for s := 1; s < workers; s++ {
+		i := start[s]
+		for j := 0; j < cols; j++ {
+			l := buf[i*cols+j]
+			if l == 0 {
+				continue
+			}
+			for dj := -1; dj <= 1; dj++ {
+				if (conn == 4 && dj != 0) || j+dj < 0 || j+dj >= cols {
+					continue
+				}
+				u := buf[(i-1)*cols+j+dj]
+				if u != 0 {
+					uf.Union(offset[s]+l, offset[s-1]+u)
+				}
+			}
+		}
+	}
+
+	// Number the merged components in order of first appearance.
+	final := make([]int, offset[workers]+1)
+	for i := range final {
+		final[i] = -1
+	}
+	final[0] = 0
+	ncomp := 1
+	for g := 1; g < len(final); g++ {
+		r := uf.Find(g)
+		if final[r] == -1 {
+			final[r] = ncomp
+			ncomp++
+		}
+		final[g] = final[r]
+	}
+
+	// Relabel the strips
+	for s := 0; s < workers; s++ {
+		wg.Add(1)
+		go func(s int) {
+			defer wg.Done()
+			for i := start[s] * cols; i < start[s+1]*cols; i++ {
+				if l := buf[i]; l != 0 {
+					buf[i] = final[offset[s]+l]
+				}
+			}
+		}(s)
+	}
+	wg.Wait()
+
+	return &Label{
+		rows:     rows,
+		cols:     cols,
+		conn:     conn,
+		preserve: true,
+		mask:     mask,
+		labels:   buf,
+		ncomp:    ncomp,
+	}
+}
@@ -0,0 +1,51 @@
+package tda
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestLabelParallel(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(43))
+
+	for jt := 0; jt < 20; jt++ {
+
+		rows := 5 + rng.Intn(30)
+		cols := 1 + rng.Intn(30)
+		mask := make([]uint8, rows*cols)
+		for i := range mask {
+			if rng.Float64() < 0.5 {
+				mask[i] = 1
+			}
+		}
+
+		for _, conn := range []int{4, 8} {
+
+			la := NewLabelPreserve(mask, rows, conn, nil)
+
+			for _, workers := range []int{1, 2, 3, 5, rows + 1} {
+
+				lp := NewLabelParallel(mask, rows, conn, workers, nil)
+
+				if !compareLabels(lp.Labels(), la.Labels()) {
+					fmt.Printf("Labels do not match in test %d with conn=%d, workers=%d\n",
+						jt, conn, workers)
+					t.Fail()
+				}
+
+				if lp.NumComponents() != la.NumComponents() {
+					fmt.Printf("NumComponents is %d, expected %d\n",
+						lp.NumComponents(), la.NumComponents())
+					t.Fail()
+				}
+
+				if !compareBboxes(lp.Bboxes(nil), la.Bboxes(nil)) {
+					fmt.Printf("Bounding boxes do not match in test %d\n", jt)
+					t.Fail()
+				}
+			}
+		}
+	}
+}
@@ -344,3 +344,200 @@ func TestLabel1(t *testing.T) {
 		}
 	}
 }
+
+func TestLabelConn(t *testing.T) {
+
+	img := []string{
+		"00000000",
+		"01100000",
+		"01101100",
+		"00010100",
+		"00100110",
+		"01000000",
+		"00000000",
+	}
+
+	for _, tr := range []struct {
+		conn  int
+		elab  []string
+		sizes []int
+		ncomp int
+	}{
+		{
+			conn: 4,
+			elab: []string{
+				"00000000",
+				"01100000",
+				"01102200",
+				"00030200",
+				"00400220",
+				"05000000",
+				"00000000",
+			},
+			sizes: []int{44, 4, 5, 1, 1, 1},
+			ncomp: 6,
+		},
+		{
+			conn: 8,
+			elab: []string{
+				"00000000",
+				"01100000",
+				"01101100",
+				"00010100",
+				"00100110",
+				"01000000",
+				"00000000",
+			},
+			sizes: []int{44, 12},
+			ncomp: 2,
+		},
+	} {
+		mask := unpack(img)
+		la := NewLabelConn(mask, len(img), tr.conn, nil)
+
+		if !compareLabels(la.Labels(), lunpack(tr.elab)) {
+			fmt.Printf("Labels do not match with conn=%d\n", tr.conn)
+			fmt.Printf("Got:\n")
+			lprint(la.Labels(), len(img))
+			t.Fail()
+		}
+
+		if la.NumComponents() != tr.ncomp {
+			fmt.Printf("NumComponents is %d, should be %d with conn=%d\n",
+				la.NumComponents(), tr.ncomp, tr.conn)
+			t.Fail()
+		}
+
+		if !compareSizes(la.Sizes(nil), tr.sizes) {
+			fmt.Printf("Sizes do not match with conn=%d\n", tr.conn)
+			fmt.Printf("Got:\n%v\n", la.Sizes(nil))
+			t.Fail()
+		}
+	}
+}
+
+func TestLabelPreserve(t *testing.T) {
+
+	for jt, tr := range []struct {
+		img    []string
+		conn   int
+		elab   []string
+		bboxes []image.Rectangle
+		sizes  []int
+		ncomp  int
+	}{
+		{
+			img: []string{
+				"1100011",
+				"1000010",
+				"0001000",
+				"0010001",
+				"1110011",
+			},
+			conn: 8,
+			elab: []string{
+				"1100022",
+				"1000020",
+				"0003000",
+				"0030004",
+				"3330044",
+			},
+			bboxes: []image.Rectangle{
+				image.Rect(0, 0, 7, 5),
+				image.Rect(0, 0, 2, 2),
+				image.Rect(5, 0, 7, 2),
+				image.Rect(0, 2, 4, 5),
+				image.Rect(5, 3, 7, 5),
+			},
+			sizes: []int{21, 3, 3, 5, 3},
+			ncomp: 5,
+		},
+		{
+			img: []string{
+				"1100011",
+				"1000010",
+				"0001000",
+				"0010001",
+				"1110011",
+			},
+			conn: 4,
+			elab: []string{
+				"1100022",
+				"1000020",
+				"0003000",
+				"0040005",
+				"4440055",
+			},
+			sizes: []int{21, 3, 3, 1, 4, 3},
+			ncomp: 6,
+		},
+		{
+			// No background pixels
+			img: []string{
+				"111",
+				"111",
+			},
+			conn: 8,
+			elab: []string{
+				"111",
+				"111",
+			},
+			sizes: []int{0, 6},
+			ncomp: 2,
+		},
+		{
+			// A single column
+			img: []string{
+				"1",
+				"1",
+				"0",
+				"1",
+			},
+			conn: 4,
+			elab: []string{
+				"1",
+				"1",
+				"0",
+				"2",
+			},
+			sizes: []int{1, 2, 1},
+			ncomp: 3,
+		},
+	} {
+		mask := unpack(tr.img)
+		la := NewLabelPreserve(mask, len(tr.img), tr.conn, nil)
+
+		if !compareLabels(la.Labels(), lunpack(tr.elab)) {
+			fmt.Printf("Labels do not match in test %d\n", jt)
+			fmt.Printf("Got:\n")
+			lprint(la.Labels(), len(tr.img))
+			t.Fail()
+		}
+
+		if la.NumComponents() != tr.ncomp {
+			fmt.Printf("NumComponents is %d, should be %d in test %d\n",
+				la.NumComponents(), tr.ncomp, jt)
+			t.Fail()
+		}
+
+		if !compareSizes(la.Sizes(nil), tr.sizes) {
+			fmt.Printf("Sizes do not match in test %d\n", jt)
+			fmt.Printf("Got:\n%v\n", la.Sizes(nil))
+			t.Fail()
+		}
+
+		if tr.bboxes != nil && !compareBboxes(la.Bboxes(nil), tr.bboxes) {
+			fmt.Printf("Bounding boxes do not match in test %d.\n", jt)
+			fmt.Printf("Got %v, expected %v.\n", la.Bboxes(nil), tr.bboxes)
+			t.Fail()
+		}
+
+		for i, v := range unpack(tr.img) {
+			if mask[i] != v {
+				fmt.Printf("Mask was modified in test %d\n", jt)
+				t.Fail()
+				break
+			}
+		}
+	}
+}
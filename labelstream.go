@@ -0,0 +1,316 @@
+package tda
+
+import (
+	"fmt"
+	"image"
+	"io"
+)
+
+// LabelStream finds the connected components in a binary image that
+// is provided as a sequence of bands, each consisting of one or more
+// complete rows.  Only the last row of the previous band and a
+// summary of each provisional label are retained, so the full image
+// never needs to be held in memory.  The summaries are never
+// discarded, so the memory used grows with the total number of
+// provisional labels in the image, not with the size of a band.
+//
+// Each band is assigned provisional labels, which may later be found
+// to belong to the same component as other provisional labels, so the
+// labels returned for a band are not final until the whole image has
+// been processed.  After all bands have been processed and Finish has
+// been called, the provisional labels can be converted to final
+// labels with Final.
+// The final labels are numbered in the order in which the components
+// are first encountered in raster order, with 0 denoting the
+// background.
+type LabelStream struct {
+
+	// The number of columns in the image
+	cols int
+
+	// The connectivity, 4 or 8
+	conn int
+
+	// The number of rows processed so far
+	rows int
+
+	// The provisional labels of the last processed row
+	prev []int
+
+	// A union-find structure for the provisional labels, the
+	// root of each set is its least element.
+	parent []int
+
+	// The size and bounding box of each provisional label
+	size  []int
+	bboxs []image.Rectangle
+
+	// Maps provisional labels to final labels, set by Finish
+	final []int
+
+	// The number of components, including the background
+	ncomp int
+}
+
+// NewLabelStream returns a LabelStream for labeling an image with
+// the given number of columns, using the given connectivity (4 or 8,
+// see NewLabelConn).  Pixels outside of the image are treated as
+// background.
+func NewLabelStream(cols, conn int) *LabelStream {
+
+	if conn != 4 && conn != 8 {
+		panic("conn must be 4 or 8")
+	}
+
+	return &LabelStream{
+		cols: cols,
+		conn: conn,
+		prev: make([]int, cols),
+
+		// Provisional label 0 is the background
+		parent: []int{0},
+		size:   []int{0},
+		bboxs:  []image.Rectangle{{}},
+	}
+}
+
+func (ls *LabelStream) find(x int) int {
+	for ls.parent[x] != x {
+		ls.parent[x] = ls.parent[ls.parent[x]]
+		x = ls.parent[x]
+	}
+	return x
+}
+
+func (ls *LabelStream) union(x, y int) {
+	x = ls.find(x)
+	y = ls.find(y)
+	if x < y {
+		ls.parent[y] = x
+	} else if y < x {
+		ls.parent[x] = y
+	}
+}
+
+// Next labels the next band of the image, which must contain a whole
+// number of rows.  The provisional labels of the band are returned,
+// using buf if it is large enough.  The returned labels can be
+// converted to final labels with Final after Finish is called.
+func (ls *LabelStream) Next(band []uint8, buf []int) []int {
+
+	if ls.final != nil {
+		panic("Next cannot be called after Finish")
+	}
+
+	c := ls.cols
+	if len(band)%c != 0 {
+		panic("band does not contain a whole number of rows")
+	}
+
+	if cap(buf) < len(band) {
+		buf = make([]int, len(band))
+	} else {
+		buf = buf[0:len(band)]
+	}
+
+	for i := 0; i < len(band)/c; i++ {
+
+		row := band[i*c : (i+1)*c]
+		lab := buf[i*c : (i+1)*c]
+		y := ls.rows
+
+		for j := 0; j < c; {
+
+			if row[j] == 0 {
+				lab[j] = 0
+				j++
+				continue
+			}
+
+			// Find the run [j1, j2)
+			j1 := j
+			for j < c && row[j] != 0 {
+				j++
+			}
+			j2 := j
+
+			// The range of columns in the previous row that
+			// are adjacent to the run
+			k1, k2 := j1, j2
+			if ls.conn == 8 {
+				if k1 > 0 {
+					k1--
+				}
+				if k2 < c {
+					k2++
+				}
+			}
+
+			v := 0
+			for k := k1; k < k2; k++ {
+				if ls.prev[k] == 0 {
+					continue
+				}
+				if v == 0 {
+					v = ls.prev[k]
+				} else {
+					ls.union(v, ls.prev[k])
+				}
+			}
+
+			if v == 0 {
+				// Starting a new region
+				v = len(ls.parent)
+				ls.parent = append(ls.parent, v)
+				ls.size = append(ls.size, 0)
+				ls.bboxs = append(ls.bboxs, image.Rect(j1, y, j2, y+1))
+			}
+
+			for k := j1; k < j2; k++ {
+				lab[k] = v
+			}
+			ls.size[v] += j2 - j1
+			ls.bboxs[v] = ls.bboxs[v].Union(image.Rect(j1, y, j2, y+1))
+		}
+
+		copy(ls.prev, lab)
+		ls.rows++
+	}
+
+	return buf
+}
+
+// ReadBands reads the image from r, in which each byte is a pixel that
+// is either zero (background) or non-zero (foreground), stored in
+// row-major order.  The image is processed in bands of the given
+// number of rows.  If fn is not nil, it is called for each band with
+// the index of the first row in the band and the provisional labels
+// of the band.  The slice of labels is reused for the next band.
+// Finish is called after the last band is processed.
+func (ls *LabelStream) ReadBands(r io.Reader, rows int, fn func(row int, labels []int)) error {
+
+	band := make([]uint8, rows*ls.cols)
+	var buf []int
+
+	for {
+		n, err := io.ReadFull(r, band)
+		if n%ls.cols != 0 {
+			return fmt.Errorf("tda: image data ends with a partial row")
+		}
+		if n > 0 {
+			row := ls.rows
+			buf = ls.Next(band[0:n], buf)
+			if fn != nil {
+				fn(row, buf)
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	ls.Finish()
+
+	return nil
+}
+
+// Finish completes the labeling.  It must be called after the last
+// band is processed, and before calling Final, NumComponents, Sizes
+// or Bboxes.
+func (ls *LabelStream) Finish() {
+
+	ls.final = make([]int, len(ls.parent))
+	ls.ncomp = 1
+
+	// Since the roots are the least elements of their sets, the
+	// roots are visited in order of first appearance.
+	for x := 1; x < len(ls.parent); x++ {
+		r := ls.find(x)
+		if r == x {
+			ls.final[x] = ls.ncomp
+			ls.ncomp++
+		} else {
+			ls.final[x] = ls.final[r]
+		}
+	}
+}
+
+// Final replaces the provisional labels in lab with final labels.
+func (ls *LabelStream) Final(lab []int) {
+
+	if ls.final == nil {
+		panic("Finish must be called before Final")
+	}
+
+	for i, v := range lab {
+		lab[i] = ls.final[v]
+	}
+}
+
+// Rows returns the number of rows processed so far.
+func (ls *LabelStream) Rows() int {
+	return ls.rows
+}
+
+// Cols returns the number of columns in the image.
+func (ls *LabelStream) Cols() int {
+	return ls.cols
+}
+
+// NumComponents returns the number of components, including the
+// background component.  The maximum component label is one less
+// than the number of components.
+func (ls *LabelStream) NumComponents() int {
+	return ls.ncomp
+}
+
+// Sizes returns the sizes (number of pixels) of every labeled
+// component, as in Label.Sizes.
+func (ls *LabelStream) Sizes(buf []int) []int {
+
+	if cap(buf) < ls.ncomp {
+		buf = make([]int, ls.ncomp)
+	} else {
+		buf = buf[0:ls.ncomp]
+		for i := range buf {
+			buf[i] = 0
+		}
+	}
+
+	n := 0
+	for x := 1; x < len(ls.parent); x++ {
+		buf[ls.final[x]] += ls.size[x]
+		n += ls.size[x]
+	}
+	buf[0] = ls.rows*ls.cols - n
+
+	return buf
+}
+
+// Bboxes returns the bounding boxes of every labeled component, as
+// in Label.Bboxes.  The bounding box in position 0 spans the image.
+func (ls *LabelStream) Bboxes(buf []image.Rectangle) []image.Rectangle {
+
+	if cap(buf) < ls.ncomp {
+		buf = make([]image.Rectangle, ls.ncomp)
+	} else {
+		buf = buf[0:ls.ncomp]
+		for i := range buf {
+			buf[i] = image.Rectangle{}
+		}
+	}
+
+	buf[0] = image.Rect(0, 0, ls.cols, ls.rows)
+	for x := 1; x < len(ls.parent); x++ {
+		f := ls.final[x]
+		if buf[f].Empty() {
+			buf[f] = ls.bboxs[x]
+		} else {
+			buf[f] = buf[f].Union(ls.bboxs[x])
+		}
+	}
+
+	return buf
+}
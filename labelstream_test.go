@@ -0,0 +1,118 @@
+package tda
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// sameLabels returns true if the two labelings define the same
+// components, and returns the map from labels in x to labels in y.
+func sameLabels(x, y []int) (map[int]int, bool) {
+
+	if len(x) != len(y) {
+		return nil, false
+	}
+
+	mp := make(map[int]int)
+	rmp := make(map[int]int)
+	for i := range x {
+		if v, ok := mp[x[i]]; ok && v != y[i] {
+			return nil, false
+		}
+		if v, ok := rmp[y[i]]; ok && v != x[i] {
+			return nil, false
+		}
+		mp[x[i]] = y[i]
+		rmp[y[i]] = x[i]
+	}
+
+	return mp, mp[0] == 0
+}
+
+func TestLabelStream(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(42))
+	rows, cols := 23, 17
+
+	for jt := 0; jt < 20; jt++ {
+		for _, conn := range []int{4, 8} {
+
+			mask := make([]uint8, rows*cols)
+			for i := range mask {
+				if rng.Float64() < 0.55 {
+					mask[i] = 1
+				}
+			}
+
+			la := NewLabelPreserve(mask, rows, conn, nil)
+
+			// Stream the image in bands of 4 rows, the
+			// last band is partial.
+			ls := NewLabelStream(cols, conn)
+			lab := make([]int, 0, rows*cols)
+			err := ls.ReadBands(bytes.NewReader(mask), 4, func(row int, labels []int) {
+				if row != len(lab)/cols {
+					fmt.Printf("Band starts at row %d, expected %d\n", row, len(lab)/cols)
+					t.Fail()
+				}
+				lab = append(lab, labels...)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			ls.Final(lab)
+
+			if ls.Rows() != rows {
+				fmt.Printf("Streamed %d rows, expected %d\n", ls.Rows(), rows)
+				t.Fail()
+			}
+
+			mp, ok := sameLabels(lab, la.Labels())
+			if !ok {
+				fmt.Printf("Streamed labels do not match in test %d with conn=%d\n", jt, conn)
+				t.Fail()
+				continue
+			}
+
+			if ls.NumComponents() != la.NumComponents() {
+				fmt.Printf("NumComponents is %d, expected %d\n",
+					ls.NumComponents(), la.NumComponents())
+				t.Fail()
+				continue
+			}
+
+			sizes := ls.Sizes(nil)
+			esizes := la.Sizes(nil)
+			bboxes := ls.Bboxes(nil)
+			ebboxes := la.Bboxes(nil)
+			for k := 1; k < len(sizes); k++ {
+				if sizes[k] != esizes[mp[k]] {
+					fmt.Printf("Size of component %d is %d, expected %d\n",
+						k, sizes[k], esizes[mp[k]])
+					t.Fail()
+				}
+				if bboxes[k] != ebboxes[mp[k]] {
+					fmt.Printf("Bounding box of component %d is %v, expected %v\n",
+						k, bboxes[k], ebboxes[mp[k]])
+					t.Fail()
+				}
+			}
+			if sizes[0] != esizes[0] {
+				fmt.Printf("Background size is %d, expected %d\n", sizes[0], esizes[0])
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestLabelStreamPartialRow(t *testing.T) {
+
+	ls := NewLabelStream(4, 8)
+	err := ls.ReadBands(bytes.NewReader([]uint8{1, 0, 0, 1, 1, 1}), 2, nil)
+	if err == nil {
+		fmt.Printf("Expected an error for a partial row\n")
+		t.Fail()
+	}
+}
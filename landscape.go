@@ -6,28 +6,28 @@ import (
 )
 
 // Landscape supports construction of landscape diagrams for
-// describing the persistence homology of an image.
+// describing the persistence homology of an image.  Each depth of the
+// landscape is a piecewise linear function, which is represented
+// exactly by its breakpoints (Bubenik and Dlotko, 2017, Journal of
+// Symbolic Computation 78).
 type Landscape struct {
 
-	// Birth times
-	birth []float64
+	// The breakpoints of the landscape function at each depth, in
+	// order of increasing x coordinate.  The function is zero
+	// outside of the range of the breakpoints, and the depths
+	// beyond the last one are identically zero.
+	levels [][]LandscapePoint
 
-	// Death times
-	death []float64
-
-	// Average of birth and death times
-	bda []float64
-
-	// Distinct birth or death times
-	distinct []float64
-
-	// The observed intervals that are in each elementary interval
-	index [][]int
-
-	// The minimum and maximum of the distinct birth and death times
+	// The minimum and maximum of the birth and death times
 	min, max float64
 }
 
+// LandscapePoint is a breakpoint of a piecewise linear landscape
+// function.
+type LandscapePoint struct {
+	X, Y float64
+}
+
 // NewLandscape returns a Landscape value for the given object birth
 // and death times.  Call the Eval method to evaluate the landscape
 // function at prescribed depths.
@@ -37,75 +37,129 @@ func NewLandscape(birth, death []float64) *Landscape {
 		panic("birth and death slices must have the same length")
 	}
 
-	ls := &Landscape{
-		birth: birth,
-		death: death,
-	}
-
-	ls.init()
+	ls := &Landscape{}
+	ls.init(birth, death)
 
 	return ls
 }
 
-func (ls *Landscape) init() {
-
-	// All birth and death times.
-	n := len(ls.birth)
-	di := make([]float64, 2*n)
-	copy(di[0:n], ls.birth)
-	copy(di[n:], ls.death)
-	sort.Float64Slice(di).Sort()
-
-	// Deduplicate
-	j := 1
-	for i := 1; i < len(di); i++ {
-		if di[i] != di[i-1] {
-			di[j] = di[i]
-			j++
+func (ls *Landscape) init(birth, death []float64) {
+
+	for i := range birth {
+		if i == 0 || math.Min(birth[i], death[i]) < ls.min {
+			ls.min = math.Min(birth[i], death[i])
+		}
+		if i == 0 || math.Max(birth[i], death[i]) > ls.max {
+			ls.max = math.Max(birth[i], death[i])
 		}
 	}
-	di = di[0:j]
-	ls.distinct = di
 
-	mn := di[0]
-	mx := di[0]
-	for i := range di {
-		if di[i] < mn {
-			mn = di[i]
-		}
-		if di[i] > mx {
-			mx = di[i]
+	// The intervals that contribute to the landscape, sorted by
+	// increasing birth time, and by decreasing death time among
+	// intervals with the same birth time.
+	type interval struct {
+		b, d float64
+	}
+	var a []interval
+	for i := range birth {
+		if birth[i] < death[i] {
+			a = append(a, interval{birth[i], death[i]})
 		}
 	}
-	ls.min = mn
-	ls.max = mx
-
-	// Determine which observed intervals cover each elementary
-	// interval.
-	ls.index = make([][]int, len(di))
-	for i := range ls.birth {
-		j0 := sort.SearchFloat64s(di, ls.birth[i])
-		j1 := sort.SearchFloat64s(di, ls.death[i])
-		for j := j0; j < j1; j++ {
-			ls.index[j] = append(ls.index[j], i)
+	sort.Slice(a, func(i, j int) bool {
+		if a[i].b != a[j].b {
+			return a[i].b < a[j].b
+		}
+		return a[i].d > a[j].d
+	})
+
+	// Each depth is traced out from left to right, by following
+	// the current tent until its descending side meets the
+	// ascending side of the next tent that extends beyond it.
+	// The remainder of a tent that is passed over in this way
+	// belongs to the following depths, and is returned to the
+	// list.
+	for len(a) > 0 {
+
+		u := a[0]
+		a = a[1:]
+		p := 0
+		lev := []LandscapePoint{{u.b, 0}, {(u.b + u.d) / 2, (u.d - u.b) / 2}}
+
+		for {
+			for p < len(a) && a[p].d <= u.d {
+				p++
+			}
+			if p == len(a) {
+				lev = append(lev, LandscapePoint{u.d, 0})
+				break
+			}
+
+			v := a[p]
+			a = append(a[:p], a[p+1:]...)
+			if v.b > u.d {
+				lev = append(lev, LandscapePoint{u.d, 0})
+			}
+			if v.b >= u.d {
+				lev = append(lev, LandscapePoint{v.b, 0})
+			} else {
+				lev = append(lev, LandscapePoint{(v.b + u.d) / 2, (u.d - v.b) / 2})
+
+				// Return the part of v that lies under u,
+				// which is the interval (v.b, u.d), keeping
+				// the list sorted.  It does not extend beyond
+				// v, so it can be skipped while tracing this
+				// depth.
+				w := interval{v.b, u.d}
+				q := sort.Search(len(a), func(i int) bool {
+					return a[i].b > w.b || (a[i].b == w.b && a[i].d < w.d)
+				})
+				a = append(a, interval{})
+				copy(a[q+1:], a[q:])
+				a[q] = w
+				if q <= p {
+					p++
+				}
+			}
+			lev = append(lev, LandscapePoint{(v.b + v.d) / 2, (v.d - v.b) / 2})
+			u = v
 		}
+
+		ls.levels = append(ls.levels, lev)
 	}
+}
 
-	// Birth/death mid-points
-	ls.bda = make([]float64, len(ls.birth))
-	for i := range ls.birth {
-		ls.bda[i] = (ls.birth[i] + ls.death[i]) / 2
+// NumDepths returns the number of depths at which the landscape
+// function is not identically zero.
+func (ls *Landscape) NumDepths() int {
+	return len(ls.levels)
+}
+
+// Breakpoints returns the breakpoints of the landscape function at the
+// given depth, in order of increasing x coordinate.  The landscape
+// function is the linear interpolation of the breakpoints, and is zero
+// outside of their range.  The first and last breakpoints lie on the
+// x axis.  The returned slice is nil if the landscape function is zero
+// at the given depth.  It must not be modified.
+func (ls *Landscape) Breakpoints(depth int) []LandscapePoint {
+	if depth < 0 || depth >= len(ls.levels) {
+		return nil
 	}
+	return ls.levels[depth]
 }
 
-func maxi(x []int) int {
-	m := x[0]
-	for i := range x {
-		if x[i] > m {
-			m = x[i]
-		}
+// evalLevel evaluates the piecewise linear function with the given
+// breakpoints at t, using a binary search.
+func evalLevel(lev []LandscapePoint, t float64) float64 {
+
+	if len(lev) == 0 || t <= lev[0].X || t >= lev[len(lev)-1].X {
+		return 0
 	}
-	return m
+
+	k := sort.Search(len(lev), func(k int) bool { return lev[k].X > t })
+	p, q := lev[k-1], lev[k]
+
+	return p.Y + (q.Y-p.Y)*(t-p.X)/(q.X-p.X)
 }
 
 // Eval evaluates the landscape function at a given point t, at a
@@ -114,44 +168,87 @@ func maxi(x []int) int {
 // landscape profile etc.
 func (ls *Landscape) Eval(t float64, depth []int) []float64 {
 
-	ii := sort.SearchFloat64s(ls.distinct, t)
+	x := make([]float64, len(depth))
+	for j, d := range depth {
+		x[j] = evalLevel(ls.Breakpoints(d), t)
+	}
+
+	return x
+}
+
+// segmentPower returns the integral of |y|^p over a segment of width w
+// on which y changes linearly from y0 to y1.
+func segmentPower(w, y0, y1, p float64) float64 {
+
+	// Split the segment where it crosses zero
+	if (y0 < 0 && y1 > 0) || (y0 > 0 && y1 < 0) {
+		w0 := w * y0 / (y0 - y1)
+		return segmentPower(w0, y0, 0, p) + segmentPower(w-w0, 0, y1, p)
+	}
 
-	// The evaluation point does not fall under any tents.
-	if ii == 0 || ii == len(ls.distinct) {
-		return make([]float64, len(depth))
+	// The difference of powers below loses precision when the
+	// values are close, in which case the midpoint rule is accurate.
+	y0, y1 = math.Abs(y0), math.Abs(y1)
+	if math.Abs(y1-y0) <= 1e-6*math.Max(y0, y1) {
+		return w * math.Pow((y0+y1)/2, p)
 	}
 
-	if ls.distinct[ii] != t {
-		ii--
+	return w * (math.Pow(y1, p+1) - math.Pow(y0, p+1)) / ((p + 1) * (y1 - y0))
+}
+
+// Integral returns the exact integral of the landscape function at the
+// given depth.
+func (ls *Landscape) Integral(depth int) float64 {
+
+	lev := ls.Breakpoints(depth)
+	var s float64
+	for k := 1; k < len(lev); k++ {
+		s += (lev[k].X - lev[k-1].X) * (lev[k].Y + lev[k-1].Y) / 2
+	}
+
+	return s
+}
+
+// Norm returns the exact Lp norm of the landscape function at the given
+// depth, for p >= 1.  If p is +Inf, the maximum absolute value is
+// returned.
+func (ls *Landscape) Norm(depth int, p float64) float64 {
+
+	if p < 1 {
+		panic("p must be at least 1")
 	}
 
-	x := make([]float64, len(ls.birth))
-	j := 0
-	for _, i := range ls.index[ii] {
-		if t <= ls.bda[i] {
-			x[j] = t - ls.birth[i]
-			j++
-		} else if t < ls.death[i] {
-			x[j] = ls.death[i] - t
-			j++
+	lev := ls.Breakpoints(depth)
+	if math.IsInf(p, 1) {
+		var m float64
+		for _, q := range lev {
+			m = math.Max(m, math.Abs(q.Y))
 		}
+		return m
 	}
-	x = x[0:j]
 
-	// Zeros are not included above, append them here if needed
-	mx := maxi(depth)
-	for len(x) <= mx {
-		x = append(x, 0)
+	var s float64
+	for k := 1; k < len(lev); k++ {
+		s += segmentPower(lev[k].X-lev[k-1].X, lev[k-1].Y, lev[k].Y, p)
 	}
 
-	sort.Sort(sort.Reverse(sort.Float64Slice(x)))
+	return math.Pow(s, 1/p)
+}
+
+// Max returns the location and value of the maximum of the landscape
+// function at the given depth.  If the maximum is attained at several
+// points, the leftmost one is returned.  If the function is zero, the
+// returned values are zero.
+func (ls *Landscape) Max(depth int) (float64, float64) {
 
-	// Get the requested positions
-	for p, q := range depth {
-		x[p] = x[q]
+	var x, y float64
+	for k, q := range ls.Breakpoints(depth) {
+		if k == 0 || q.Y > y {
+			x, y = q.X, q.Y
+		}
 	}
 
-	return x
+	return x, y
 }
 
 // Stat contains summary statistics about a landscape or convex peel
@@ -164,43 +261,35 @@ type Stat struct {
 }
 
 // Stats obtains the area, perimeter, and centroid for a series of
-// landscape profiles.  The landscape function is evaluated on a grid
-// of npoints points over the range of the landscape function.
+// landscape profiles.  The statistics are calculated exactly from the
+// breakpoints, over the range of the landscape function, so the
+// perimeter includes the segments on which the function is zero.  The
+// centroid is the mean of the points on the graph of the function with
+// respect to the horizontal coordinate.  The npoints argument is
+// ignored, and is retained for compatibility.
 func (ls *Landscape) Stats(depth []int, npoints int) []Stat {
 
-	d := (ls.max - ls.min) / float64(npoints-1)
-
 	r := make([]Stat, len(depth))
+	w := ls.max - ls.min
 
-	lastx := ls.Eval(ls.min, depth)
-	for i := 1; i < npoints; i++ {
-		t := ls.min + float64(i)*d
-		x := ls.Eval(t, depth)
-
-		for j := range depth {
-			// Area
-			r[j].Area += d * (x[j] + lastx[j]) / 2
-
-			// Perimeter
-			u := lastx[j] - x[j]
-			r[j].Perimeter += math.Sqrt(d*d + u*u)
-
-			// Centroid
-			r[j].Centroid[0] += t
-			r[j].Centroid[1] += x[j]
-			if i == 1 {
-				r[j].Centroid[0] += ls.min
-				r[j].Centroid[1] += lastx[j]
-			}
-		}
+	for j, k := range depth {
 
-		lastx = x
-	}
+		pts := []LandscapePoint{{X: ls.min}}
+		pts = append(pts, ls.Breakpoints(k)...)
+		pts = append(pts, LandscapePoint{X: ls.max})
 
-	for j := range depth {
-		r[j].Centroid[0] /= float64(npoints)
-		r[j].Centroid[1] /= float64(npoints)
-		r[j].Depth = float64(depth[j])
+		for i := 1; i < len(pts); i++ {
+			dx := pts[i].X - pts[i-1].X
+			dy := pts[i].Y - pts[i-1].Y
+			r[j].Area += dx * (pts[i].Y + pts[i-1].Y) / 2
+			r[j].Perimeter += math.Sqrt(dx*dx + dy*dy)
+		}
+
+		r[j].Depth = float64(k)
+		r[j].Centroid[0] = (ls.min + ls.max) / 2
+		if w > 0 {
+			r[j].Centroid[1] = r[j].Area / w
+		}
 	}
 
 	return r
@@ -0,0 +1,171 @@
+package tda
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/stat"
+)
+
+// LandscapeBandOptions contains optional settings for
+// LandscapeBands.  The zero value gives the default settings.
+type LandscapeBandOptions struct {
+
+	// The number of bootstrap samples, the default is 1000.
+	NumBoot int
+
+	// The confidence level of the bands, the default is 0.95.
+	Level float64
+
+	// The seed for the random number generator used to draw the
+	// bootstrap samples.  The same seed gives the same bands.
+	Seed int64
+}
+
+// LandscapeBand contains the mean landscape function at one depth,
+// evaluated on a grid of points, along with bootstrap confidence
+// bands.
+type LandscapeBand struct {
+
+	// The depth of the landscape function
+	Depth int
+
+	// The points at which the landscape function is evaluated
+	T []float64
+
+	// The mean landscape function at each point
+	Mean []float64
+
+	// The lower and upper limits of the pointwise confidence band,
+	// which covers the population mean landscape at each point with
+	// the given confidence level.
+	PointLower, PointUpper []float64
+
+	// The lower and upper limits of the simultaneous confidence
+	// band, which covers the population mean landscape at all
+	// points at once with the given confidence level.
+	SimLower, SimUpper []float64
+}
+
+// LandscapeBands returns the mean of the landscapes of a collection of
+// persistence diagrams, given as slices of birth and death times, at
+// each of the given depths, evaluated at the points in tvals.
+// Bootstrap confidence bands for the population mean landscape are
+// obtained by resampling the diagrams with replacement.  The
+// pointwise bands are the percentile intervals of the bootstrap means
+// at each point.  The simultaneous bands are the mean plus or minus
+// the quantile of the greatest absolute deviation of the bootstrap
+// means from the mean (Chazal et al., 2015, Journal of Computational
+// Geometry 6:2).
+//
+// The mean is returned on the grid so that it can be compared directly
+// with the bands, which are only defined at the grid points.  The exact
+// mean landscape, represented by its breakpoints, can be obtained with
+// MeanLandscape.
+func LandscapeBands(birth, death [][]float64, depth []int, tvals []float64, opts LandscapeBandOptions) []LandscapeBand {
+
+	if len(birth) != len(death) {
+		panic("birth and death must have the same length")
+	}
+	n := len(birth)
+	if n == 0 {
+		panic("no diagrams")
+	}
+
+	nboot := opts.NumBoot
+	if nboot == 0 {
+		nboot = 1000
+	}
+	if nboot < 0 {
+		panic("NumBoot must be non-negative")
+	}
+	level := opts.Level
+	if level == 0 {
+		level = 0.95
+	}
+	if level <= 0 || level >= 1 {
+		panic("level must be between 0 and 1")
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+
+	// The landscape of each diagram at each depth and point
+	nt := len(tvals)
+	vals := make([][][]float64, n)
+	for i := range vals {
+		ls := NewLandscape(birth[i], death[i])
+		vals[i] = make([][]float64, len(depth))
+		for j := range depth {
+			vals[i][j] = make([]float64, nt)
+		}
+		for k, t := range tvals {
+			for j, x := range ls.Eval(t, depth) {
+				vals[i][j][k] = x
+			}
+		}
+	}
+
+	bands := make([]LandscapeBand, len(depth))
+	for j, d := range depth {
+		bands[j] = LandscapeBand{
+			Depth:      d,
+			T:          tvals,
+			Mean:       make([]float64, nt),
+			PointLower: make([]float64, nt),
+			PointUpper: make([]float64, nt),
+			SimLower:   make([]float64, nt),
+			SimUpper:   make([]float64, nt),
+		}
+		for i := range vals {
+			for k, x := range vals[i][j] {
+				bands[j].Mean[k] += x / float64(n)
+			}
+		}
+	}
+
+	// The bootstrap means at each depth and point, and the
+	// greatest absolute deviation of each bootstrap mean from the
+	// mean at each depth.
+	boot := make([][][]float64, len(depth))
+	dev := make([][]float64, len(depth))
+	for j := range depth {
+		boot[j] = make([][]float64, nt)
+		for k := range boot[j] {
+			boot[j][k] = make([]float64, nboot)
+		}
+		dev[j] = make([]float64, nboot)
+	}
+	for b := 0; b < nboot; b++ {
+		for r := 0; r < n; r++ {
+			i := rng.Intn(n)
+			for j := range depth {
+				for k, x := range vals[i][j] {
+					boot[j][k][b] += x / float64(n)
+				}
+			}
+		}
+		for j := range depth {
+			for k := range tvals {
+				dev[j][b] = math.Max(dev[j][b], math.Abs(boot[j][k][b]-bands[j].Mean[k]))
+			}
+		}
+	}
+
+	alpha := 1 - level
+	for j := range depth {
+		for k := range tvals {
+			x := boot[j][k]
+			sort.Float64s(x)
+			bands[j].PointLower[k] = stat.Quantile(alpha/2, stat.Empirical, x, nil)
+			bands[j].PointUpper[k] = stat.Quantile(1-alpha/2, stat.Empirical, x, nil)
+		}
+		sort.Float64s(dev[j])
+		c := stat.Quantile(level, stat.Empirical, dev[j], nil)
+		for k, m := range bands[j].Mean {
+			bands[j].SimLower[k] = m - c
+			bands[j].SimUpper[k] = m + c
+		}
+	}
+
+	return bands
+}
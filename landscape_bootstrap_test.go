@@ -0,0 +1,89 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestLandscapeBands(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(151))
+
+	var birth, death [][]float64
+	var ls []*Landscape
+	for i := 0; i < 20; i++ {
+		n := 3 + rng.Intn(5)
+		b := make([]float64, n)
+		d := make([]float64, n)
+		for k := range b {
+			b[k] = 5 * rng.Float64()
+			d[k] = b[k] + 3*rng.Float64()
+		}
+		birth = append(birth, b)
+		death = append(death, d)
+		ls = append(ls, NewLandscape(b, d))
+	}
+
+	var tvals []float64
+	for k := 0; k <= 50; k++ {
+		tvals = append(tvals, float64(k)/5)
+	}
+	depth := []int{0, 2}
+
+	bands := LandscapeBands(birth, death, depth, tvals, LandscapeBandOptions{NumBoot: 200, Seed: 3})
+	mn := MeanLandscape(ls)
+	for j, band := range bands {
+		if band.Depth != depth[j] || len(band.Mean) != len(tvals) {
+			fmt.Printf("Band %d has the wrong depth or length\n", j)
+			t.Fail()
+			continue
+		}
+		c := band.SimUpper[0] - band.Mean[0]
+		if c <= 0 {
+			fmt.Printf("Band %d has width %v\n", j, c)
+			t.Fail()
+		}
+		for k, tv := range tvals {
+			if m := mn.Eval(tv, depth[j:j+1])[0]; math.Abs(band.Mean[k]-m) > 1e-10 {
+				fmt.Printf("Band %d has mean %v at %v, expected %v\n", j, band.Mean[k], tv, m)
+				t.Fail()
+			}
+			if band.PointLower[k] > band.Mean[k]+1e-10 || band.PointUpper[k] < band.Mean[k]-1e-10 {
+				fmt.Printf("Band %d: pointwise band at %v does not contain the mean\n", j, tv)
+				t.Fail()
+			}
+			if math.Abs(band.SimUpper[k]-band.Mean[k]-c) > 1e-10 || math.Abs(band.Mean[k]-band.SimLower[k]-c) > 1e-10 {
+				fmt.Printf("Band %d: simultaneous band does not have constant width\n", j)
+				t.Fail()
+			}
+		}
+	}
+
+	// The seed determines the bands.
+	b2 := LandscapeBands(birth, death, depth, tvals, LandscapeBandOptions{NumBoot: 200, Seed: 3})
+	if !reflect.DeepEqual(bands, b2) {
+		fmt.Printf("Bands with the same seed differ\n")
+		t.Fail()
+	}
+	b3 := LandscapeBands(birth, death, depth, tvals, LandscapeBandOptions{NumBoot: 200, Seed: 4})
+	if reflect.DeepEqual(bands, b3) {
+		fmt.Printf("Bands with different seeds agree\n")
+		t.Fail()
+	}
+
+	// Identical diagrams give bands with no width.
+	birth = [][]float64{{1, 2}, {1, 2}, {1, 2}}
+	death = [][]float64{{4, 3}, {4, 3}, {4, 3}}
+	for _, band := range LandscapeBands(birth, death, depth, tvals, LandscapeBandOptions{}) {
+		for k := range tvals {
+			if math.Abs(band.SimUpper[k]-band.SimLower[k]) > 1e-12 || math.Abs(band.PointUpper[k]-band.PointLower[k]) > 1e-12 {
+				fmt.Printf("Bands of identical diagrams have positive width\n")
+				t.Fail()
+				break
+			}
+		}
+	}
+}
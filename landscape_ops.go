@@ -0,0 +1,211 @@
+package tda
+
+import (
+	"math"
+	"sort"
+)
+
+// CombineLandscapes returns the linear combination of landscapes whose
+// value at each depth is the sum of coefs[i] times the value of ls[i]
+// at that depth.  The result is exact, and its breakpoints at each
+// depth are among the union of the breakpoints of the landscapes at
+// that depth.  The result can have negative values, for example when
+// computing a difference of landscapes.
+func CombineLandscapes(coefs []float64, ls []*Landscape) *Landscape {
+
+	if len(coefs) != len(ls) {
+		panic("coefs and ls must have the same length")
+	}
+	if len(ls) == 0 {
+		panic("no landscapes to combine")
+	}
+
+	r := &Landscape{min: ls[0].min, max: ls[0].max}
+	first := true
+	nd := 0
+	for _, l := range ls {
+		if len(l.levels) == 0 {
+			continue
+		}
+		if first || l.min < r.min {
+			r.min = l.min
+		}
+		if first || l.max > r.max {
+			r.max = l.max
+		}
+		first = false
+		if len(l.levels) > nd {
+			nd = len(l.levels)
+		}
+	}
+
+	// The breakpoints of the landscapes at each depth are merged,
+	// and each landscape is evaluated at the merged breakpoints by
+	// interpolating between the breakpoints before and after its
+	// cursor.
+	levs := make([][]LandscapePoint, len(ls))
+	pos := make([]int, len(ls))
+	for d := 0; d < nd; d++ {
+
+		for i, l := range ls {
+			levs[i] = l.Breakpoints(d)
+			pos[i] = 0
+		}
+
+		var lev []LandscapePoint
+		for {
+			// The next breakpoint of any of the landscapes
+			var x float64
+			found := false
+			for i, lv := range levs {
+				if pos[i] < len(lv) && (!found || lv[pos[i]].X < x) {
+					x, found = lv[pos[i]].X, true
+				}
+			}
+			if !found {
+				break
+			}
+
+			var y float64
+			for i, lv := range levs {
+				j := pos[i]
+				for j < len(lv) && lv[j].X <= x {
+					j++
+				}
+				pos[i] = j
+				if coefs[i] == 0 || j == 0 || j == len(lv) || x <= lv[0].X {
+					continue
+				}
+				p, q := lv[j-1], lv[j]
+				y += coefs[i] * (p.Y + (q.Y-p.Y)*(x-p.X)/(q.X-p.X))
+			}
+			lev = append(lev, LandscapePoint{x, y})
+		}
+
+		r.levels = append(r.levels, trimLevel(lev))
+	}
+
+	// Remove the depths beyond the last one that is not
+	// identically zero.
+	for len(r.levels) > 0 && r.levels[len(r.levels)-1] == nil {
+		r.levels = r.levels[0 : len(r.levels)-1]
+	}
+
+	return r
+}
+
+// trimLevel removes the leading and trailing breakpoints of a
+// piecewise linear function that are not needed to represent it, so
+// that only the first and last breakpoints are zero outside of the
+// support.  If the function is identically zero, nil is returned.
+func trimLevel(lev []LandscapePoint) []LandscapePoint {
+
+	i := 0
+	for i < len(lev) && lev[i].Y == 0 {
+		i++
+	}
+	if i == len(lev) {
+		return nil
+	}
+	j := len(lev) - 1
+	for lev[j].Y == 0 {
+		j--
+	}
+	if i > 0 {
+		i--
+	}
+	if j < len(lev)-1 {
+		j++
+	}
+
+	return lev[i : j+1]
+}
+
+// Add returns the sum of two landscapes.
+func (ls *Landscape) Add(other *Landscape) *Landscape {
+	return CombineLandscapes([]float64{1, 1}, []*Landscape{ls, other})
+}
+
+// Scale returns the landscape multiplied by a constant.
+func (ls *Landscape) Scale(c float64) *Landscape {
+	return CombineLandscapes([]float64{c}, []*Landscape{ls})
+}
+
+// MeanLandscape returns the average of the given landscapes, which is
+// exact at every depth.
+func MeanLandscape(ls []*Landscape) *Landscape {
+	coefs := make([]float64, len(ls))
+	for i := range coefs {
+		coefs[i] = 1 / float64(len(ls))
+	}
+	return CombineLandscapes(coefs, ls)
+}
+
+// LandscapeDistance returns the exact Lp distance between two
+// landscapes, for p >= 1, combining all depths.  This is the p-th
+// root of the sum over the depths of the integrals of the p-th power
+// of the absolute difference.  If p is +Inf, the greatest absolute
+// difference at any depth is returned.
+func LandscapeDistance(a, b *Landscape, p float64) float64 {
+
+	if p < 1 {
+		panic("p must be at least 1")
+	}
+
+	diff := CombineLandscapes([]float64{1, -1}, []*Landscape{a, b})
+
+	if math.IsInf(p, 1) {
+		var m float64
+		for d := range diff.levels {
+			m = math.Max(m, diff.Norm(d, p))
+		}
+		return m
+	}
+
+	var s float64
+	for d := range diff.levels {
+		s += math.Pow(diff.Norm(d, p), p)
+	}
+
+	return math.Pow(s, 1/p)
+}
+
+// LandscapeInner returns the exact L2 inner product of two landscapes,
+// which is the sum over the depths of the integrals of the products
+// of the landscape functions.
+func LandscapeInner(a, b *Landscape) float64 {
+
+	nd := len(a.levels)
+	if len(b.levels) < nd {
+		nd = len(b.levels)
+	}
+
+	var s float64
+	var xs []float64
+	for d := 0; d < nd; d++ {
+		la, lb := a.levels[d], b.levels[d]
+		xs = xs[0:0]
+		for _, q := range la {
+			xs = append(xs, q.X)
+		}
+		for _, q := range lb {
+			xs = append(xs, q.X)
+		}
+		sort.Float64s(xs)
+
+		// Both functions are linear between consecutive
+		// breakpoints, so the integral of the product is exact
+		// on each segment.
+		for k := 1; k < len(xs); k++ {
+			w := xs[k] - xs[k-1]
+			if w == 0 {
+				continue
+			}
+			a0, a1 := evalLevel(la, xs[k-1]), evalLevel(la, xs[k])
+			b0, b1 := evalLevel(lb, xs[k-1]), evalLevel(lb, xs[k])
+			s += w * (2*a0*b0 + a0*b1 + a1*b0 + 2*a1*b1) / 6
+		}
+	}
+
+	return s
+}
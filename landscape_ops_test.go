@@ -0,0 +1,141 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomLandscape(rng *rand.Rand) *Landscape {
+	n := 1 + rng.Intn(8)
+	birth := make([]float64, n)
+	death := make([]float64, n)
+	for i := range birth {
+		birth[i] = float64(rng.Intn(10))
+		death[i] = birth[i] + float64(1+rng.Intn(8))
+	}
+	return NewLandscape(birth, death)
+}
+
+func TestCombineLandscapes(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(141))
+
+	for jt := 0; jt < 30; jt++ {
+
+		ls := []*Landscape{randomLandscape(rng), randomLandscape(rng), randomLandscape(rng)}
+		coefs := []float64{rng.NormFloat64(), rng.NormFloat64(), rng.NormFloat64()}
+		r := CombineLandscapes(coefs, ls)
+		mn := MeanLandscape(ls)
+		sum := ls[0].Add(ls[1])
+		sc := ls[2].Scale(-2)
+
+		depth := []int{0, 1, 2, 3, 4, 5, 6, 7, 8}
+		for k := 0; k <= 400; k++ {
+			tv := -1 + float64(k)/20
+			var y [3][]float64
+			for i := range ls {
+				y[i] = ls[i].Eval(tv, depth)
+			}
+			x, m, s, c := r.Eval(tv, depth), mn.Eval(tv, depth), sum.Eval(tv, depth), sc.Eval(tv, depth)
+			for d := range depth {
+				e := coefs[0]*y[0][d] + coefs[1]*y[1][d] + coefs[2]*y[2][d]
+				if math.Abs(x[d]-e) > 1e-10 {
+					fmt.Printf("Test %d: combination at %v, depth %d is %v, expected %v\n", jt, tv, d, x[d], e)
+					t.Fail()
+				}
+				e = (y[0][d] + y[1][d] + y[2][d]) / 3
+				if math.Abs(m[d]-e) > 1e-10 {
+					fmt.Printf("Test %d: mean at %v, depth %d is %v, expected %v\n", jt, tv, d, m[d], e)
+					t.Fail()
+				}
+				if math.Abs(s[d]-y[0][d]-y[1][d]) > 1e-10 || math.Abs(c[d]+2*y[2][d]) > 1e-10 {
+					fmt.Printf("Test %d: sum or scale at %v, depth %d is wrong\n", jt, tv, d)
+					t.Fail()
+				}
+			}
+		}
+
+		for d := range depth {
+			e := (ls[0].Integral(d) + ls[1].Integral(d) + ls[2].Integral(d)) / 3
+			if math.Abs(mn.Integral(d)-e) > 1e-10 {
+				fmt.Printf("Test %d: mean has integral %v at depth %d, expected %v\n", jt, mn.Integral(d), d, e)
+				t.Fail()
+			}
+			lev := r.Breakpoints(d)
+			if len(lev) > 0 && (lev[0].Y != 0 || lev[len(lev)-1].Y != 0) {
+				fmt.Printf("Test %d: breakpoints do not start and end at zero\n", jt)
+				t.Fail()
+			}
+		}
+
+		// The stats of the mean are defined
+		st := mn.Stats([]int{0, 1}, 100)
+		if math.IsNaN(st[0].Area) || st[0].Area <= 0 {
+			fmt.Printf("Test %d: mean has area %v\n", jt, st[0].Area)
+			t.Fail()
+		}
+	}
+
+	// A landscape minus itself is zero
+	a := randomLandscape(rng)
+	if z := CombineLandscapes([]float64{1, -1}, []*Landscape{a, a}); z.NumDepths() != 0 {
+		fmt.Printf("Difference has %d depths, expected 0\n", z.NumDepths())
+		t.Fail()
+	}
+}
+
+func TestLandscapeDistance(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(142))
+
+	for jt := 0; jt < 30; jt++ {
+
+		a, b := randomLandscape(rng), randomLandscape(rng)
+
+		// The L2 distance agrees with the inner products.
+		d2 := LandscapeDistance(a, b, 2)
+		e := LandscapeInner(a, a) - 2*LandscapeInner(a, b) + LandscapeInner(b, b)
+		if math.Abs(d2*d2-e) > 1e-8 {
+			fmt.Printf("Test %d: squared L2 distance is %v, expected %v\n", jt, d2*d2, e)
+			t.Fail()
+		}
+
+		// The inner product of a landscape with itself is the
+		// sum of the squared L2 norms.
+		var s float64
+		for d := 0; d < a.NumDepths(); d++ {
+			s += math.Pow(a.Norm(d, 2), 2)
+		}
+		if math.Abs(LandscapeInner(a, a)-s) > 1e-8 {
+			fmt.Printf("Test %d: inner product is %v, expected %v\n", jt, LandscapeInner(a, a), s)
+			t.Fail()
+		}
+
+		// Compare to a Riemann sum on a fine grid.
+		var l1, li float64
+		depth := []int{0, 1, 2, 3, 4, 5, 6, 7}
+		h := 0.001
+		for tv := -1.0; tv < 20; tv += h {
+			x, y := a.Eval(tv+h/2, depth), b.Eval(tv+h/2, depth)
+			for d := range depth {
+				l1 += h * math.Abs(x[d]-y[d])
+				li = math.Max(li, math.Abs(x[d]-y[d]))
+			}
+		}
+		if math.Abs(LandscapeDistance(a, b, 1)-l1) > 1e-3 {
+			fmt.Printf("Test %d: L1 distance is %v, expected %v\n", jt, LandscapeDistance(a, b, 1), l1)
+			t.Fail()
+		}
+		if math.Abs(LandscapeDistance(a, b, math.Inf(1))-li) > 1e-3 {
+			fmt.Printf("Test %d: L-infinity distance is %v, expected %v\n", jt, LandscapeDistance(a, b, math.Inf(1)), li)
+			t.Fail()
+		}
+
+		if d := LandscapeDistance(a, a, 2); d != 0 {
+			fmt.Printf("Test %d: landscape has distance %v to itself\n", jt, d)
+			t.Fail()
+		}
+	}
+}
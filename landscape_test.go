@@ -3,6 +3,8 @@ package tda
 import (
 	"fmt"
 	"math"
+	"math/rand"
+	"sort"
 	"testing"
 
 	"gonum.org/v1/gonum/floats"
@@ -30,18 +32,18 @@ var (
 			stats: []Stat{
 				{
 					Depth:     0,
-					Area:      8.999082,
-					Perimeter: 8.460178,
+					Area:      9,
+					Perimeter: 8.485281,
 				},
 				{
 					Depth:     1,
 					Area:      4,
-					Perimeter: 7.620954,
+					Perimeter: 7.656854,
 				},
 				{
 					Depth:     2,
-					Area:      0.999082,
-					Perimeter: 6.803323,
+					Area:      1,
+					Perimeter: 6.828427,
 				},
 			},
 		},
@@ -58,13 +60,13 @@ var (
 			stats: []Stat{
 				{
 					Depth:     0,
-					Area:      6.499745,
-					Perimeter: 10.449612,
+					Area:      6.5,
+					Perimeter: 10.485281,
 				},
 				{
 					Depth:     1,
-					Area:      3.248646,
-					Perimeter: 9.985452,
+					Area:      3.25,
+					Perimeter: 10.071068,
 				},
 				{
 					Depth:     2,
@@ -107,3 +109,117 @@ func TestLandscape(t *testing.T) {
 		}
 	}
 }
+
+// tentHeights returns the heights of the tent functions at t, in
+// decreasing order.
+func tentHeights(birth, death []float64, t float64) []float64 {
+	var x []float64
+	for i := range birth {
+		if h := math.Min(t-birth[i], death[i]-t); h > 0 {
+			x = append(x, h)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.Float64Slice(x)))
+	return x
+}
+
+// The breakpoints agree with the tent functions at every depth.
+func TestLandscapeBreakpoints(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(131))
+
+	for jt := 0; jt < 50; jt++ {
+
+		n := 1 + rng.Intn(10)
+		birth := make([]float64, n)
+		death := make([]float64, n)
+		for i := range birth {
+			birth[i] = float64(rng.Intn(10))
+			death[i] = birth[i] + float64(rng.Intn(8))
+		}
+		ls := NewLandscape(birth, death)
+
+		depth := make([]int, n+1)
+		for i := range depth {
+			depth[i] = i
+		}
+
+		// The breakpoints are the vertices of the landscape, so
+		// the landscape agrees with the tents on a fine grid.
+		for k := 0; k <= 800; k++ {
+			tv := -1 + float64(k)/40
+			x := ls.Eval(tv, depth)
+			y := tentHeights(birth, death, tv)
+			for len(y) < len(x) {
+				y = append(y, 0)
+			}
+			if !floats.EqualApprox(x, y, 1e-12) {
+				fmt.Printf("Test %d: landscape at %v is %v, expected %v\n", jt, tv, x, y)
+				t.Fail()
+				break
+			}
+		}
+
+		for d := range depth {
+			lev := ls.Breakpoints(d)
+			if (len(lev) > 0) != (d < ls.NumDepths()) {
+				fmt.Printf("Test %d: depth %d has %d breakpoints\n", jt, d, len(lev))
+				t.Fail()
+			}
+			for k := 1; k < len(lev); k++ {
+				if lev[k].X <= lev[k-1].X {
+					fmt.Printf("Test %d: breakpoints are not increasing at depth %d\n", jt, d)
+					t.Fail()
+				}
+			}
+
+			// Simpson's rule is exact for the integrals of the
+			// first three powers of a linear function.
+			var l1, l2, l3, mx float64
+			for k := 1; k < len(lev); k++ {
+				w := lev[k].X - lev[k-1].X
+				y0, y1 := lev[k-1].Y, lev[k].Y
+				ym := (y0 + y1) / 2
+				l1 += w * (y0 + 4*ym + y1) / 6
+				l2 += w * (y0*y0 + 4*ym*ym + y1*y1) / 6
+				l3 += w * (y0*y0*y0 + 4*ym*ym*ym + y1*y1*y1) / 6
+				mx = math.Max(mx, y1)
+			}
+			if math.Abs(ls.Integral(d)-l1) > 1e-10 || math.Abs(ls.Norm(d, 1)-l1) > 1e-10 {
+				fmt.Printf("Test %d: integral at depth %d is %v, expected %v\n", jt, d, ls.Integral(d), l1)
+				t.Fail()
+			}
+			if math.Abs(ls.Norm(d, 2)-math.Sqrt(l2)) > 1e-10 {
+				fmt.Printf("Test %d: L2 norm at depth %d is %v, expected %v\n", jt, d, ls.Norm(d, 2), math.Sqrt(l2))
+				t.Fail()
+			}
+			if math.Abs(ls.Norm(d, 3)-math.Cbrt(l3)) > 1e-10 {
+				fmt.Printf("Test %d: L3 norm at depth %d is %v, expected %v\n", jt, d, ls.Norm(d, 3), math.Cbrt(l3))
+				t.Fail()
+			}
+			xm, ym := ls.Max(d)
+			if ym != mx || ls.Norm(d, math.Inf(1)) != mx || ls.Eval(xm, []int{d})[0] != ym {
+				fmt.Printf("Test %d: maximum at depth %d is %v, expected %v\n", jt, d, ym, mx)
+				t.Fail()
+			}
+		}
+	}
+}
+
+// Segments on which the landscape is nearly constant are integrated
+// without cancellation.
+func TestSegmentPower(t *testing.T) {
+
+	for _, y0 := range []float64{1, 1e4, 1e8} {
+		y1 := y0 * (1 + 1e-9)
+		for _, p := range []float64{1, 2, 3} {
+			// A series expansion of the exact integral
+			e := 1e-9
+			exact := math.Pow(y0, p) * (1 + p*e/2 + p*(p-1)*e*e/6)
+			if v := segmentPower(2, y0, y1, p); math.Abs(v-2*exact) > 1e-12*v {
+				fmt.Printf("y0=%v, p=%v: got %v, expected %v\n", y0, p, v, 2*exact)
+				t.Fail()
+			}
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package tda
+
+import (
+	"sort"
+
+	"github.com/theodesp/unionfind"
+)
+
+// NodeType indicates the kind of critical point that is represented
+// by a node of a merge tree or contour tree.
+type NodeType int
+
+const (
+	// MaxNode is a local maximum, where a superlevel set component
+	// is born.
+	MaxNode NodeType = iota
+
+	// MinNode is a local minimum, where a sublevel set component
+	// is born.
+	MinNode
+
+	// SaddleNode is a point where level set components merge or
+	// split.
+	SaddleNode
+)
+
+// MergeNode is a node in a merge tree.
+type MergeNode struct {
+
+	// The kind of critical point
+	Type NodeType
+
+	// The linear index of the pixel at the critical point, and its
+	// intensity
+	Pixel int
+	Value int
+
+	// The index of the parent node, which has a lower value, or -1
+	// for the root.
+	Parent int
+
+	// The indices of the child nodes, which have higher values.
+	Children []int
+
+	// For a maximum, the index of the saddle node at which the
+	// branch beginning at this maximum merges into an older branch
+	// (one with a brighter maximum), or -1 if the branch is never
+	// merged.  For other nodes this is -1.
+	Death int
+}
+
+// MergeTree is the merge tree (also known as the join tree) of the
+// superlevel sets {img >= t} of an image.  The leaves of the tree
+// are the local maxima of the image, where components are born as the
+// threshold decreases, and the interior nodes are the saddles where
+// components merge.  The root is the global minimum of the image,
+// where all components have merged into one.
+type MergeTree struct {
+
+	// The dimensions of the image
+	rows int
+	cols int
+
+	// The original image being processed
+	img []int
+
+	// The nodes of the tree
+	nodes []MergeNode
+
+	// The maximum node whose branch owns each pixel
+	owner []int
+
+	// The distinct pixel intensities in increasing order
+	levels []int
+}
+
+// NewMergeTree constructs the merge tree of the superlevel sets of
+// the given image, which must be rectangular with the given number of
+// rows.  As in NewExactPersistence, the pixels are processed in order
+// of decreasing intensity using a union-find structure, pixels are
+// 8-connected, and when components merge the branch with the
+// brightest maximum continues (the elder rule).
+func NewMergeTree(img []int, rows int) *MergeTree {
+
+	cols := len(img) / rows
+	if rows*cols != len(img) {
+		panic("rows is not compatible with img")
+	}
+
+	mt := &MergeTree{
+		rows: rows,
+		cols: cols,
+		img:  img,
+	}
+
+	mt.build()
+
+	return mt
+}
+
+func (mt *MergeTree) build() {
+
+	img := mt.img
+	rows, cols := mt.rows, mt.cols
+	n := len(img)
+
+	ord := make([]int, n)
+	for i := range ord {
+		ord[i] = i
+	}
+	sort.SliceStable(ord, func(i, j int) bool {
+		return img[ord[i]] > img[ord[j]]
+	})
+
+	for i := n - 1; i >= 0; i-- {
+		v := img[ord[i]]
+		if len(mt.levels) == 0 || v != mt.levels[len(mt.levels)-1] {
+			mt.levels = append(mt.levels, v)
+		}
+	}
+
+	uf := unionfind.New(n)
+	added := make([]bool, n)
+
+	// The node at the head of each component, and the maximum
+	// node of the oldest branch in each component, indexed by
+	// the component roots.
+	head := make([]int, n)
+	branch := make([]int, n)
+
+	mt.owner = make([]int, n)
+
+	// older returns true if the branch beginning at maximum node a
+	// is older than the branch beginning at maximum node b.
+	older := func(a, b int) bool {
+		na, nb := &mt.nodes[a], &mt.nodes[b]
+		if na.Value != nb.Value {
+			return na.Value > nb.Value
+		}
+		return na.Pixel < nb.Pixel
+	}
+
+	var roots []int
+	for _, p := range ord {
+
+		added[p] = true
+		i, j := p/cols, p%cols
+
+		// The distinct components adjacent to p
+		roots = roots[0:0]
+		for di := -1; di <= 1; di++ {
+			for dj := -1; dj <= 1; dj++ {
+				i1, j1 := i+di, j+dj
+				if i1 < 0 || i1 >= rows || j1 < 0 || j1 >= cols {
+					continue
+				}
+				q := i1*cols + j1
+				if q == p || !added[q] {
+					continue
+				}
+				r := uf.Find(q)
+				dup := false
+				for _, u := range roots {
+					if u == r {
+						dup = true
+						break
+					}
+				}
+				if !dup {
+					roots = append(roots, r)
+				}
+			}
+		}
+
+		switch len(roots) {
+		case 0:
+			// A new component is born at a maximum
+			mt.nodes = append(mt.nodes, MergeNode{
+				Type:   MaxNode,
+				Pixel:  p,
+				Value:  img[p],
+				Parent: -1,
+				Death:  -1,
+			})
+			k := len(mt.nodes) - 1
+			head[p] = k
+			branch[p] = k
+			mt.owner[p] = k
+			continue
+		case 1:
+			// The pixel extends an existing component
+			r := roots[0]
+			h, b := head[r], branch[r]
+			uf.Union(p, r)
+			r = uf.Find(p)
+			head[r] = h
+			branch[r] = b
+			mt.owner[p] = b
+			continue
+		}
+
+		// Several components merge at a saddle
+		mt.nodes = append(mt.nodes, MergeNode{
+			Type:   SaddleNode,
+			Pixel:  p,
+			Value:  img[p],
+			Parent: -1,
+			Death:  -1,
+		})
+		k := len(mt.nodes) - 1
+
+		b := branch[roots[0]]
+		for _, r := range roots[1:] {
+			if older(branch[r], b) {
+				b = branch[r]
+			}
+		}
+
+		for _, r := range roots {
+			h := head[r]
+			mt.nodes[h].Parent = k
+			mt.nodes[k].Children = append(mt.nodes[k].Children, h)
+			if branch[r] != b {
+				mt.nodes[branch[r]].Death = k
+			}
+			uf.Union(p, r)
+		}
+
+		r := uf.Find(p)
+		head[r] = k
+		branch[r] = b
+		mt.owner[p] = b
+	}
+
+	// Add the global minimum as the root, unless it is already a
+	// node.
+	if n > 0 {
+		p := ord[n-1]
+		r := uf.Find(p)
+		if h := head[r]; mt.nodes[h].Pixel != p {
+			mt.nodes = append(mt.nodes, MergeNode{
+				Type:     MinNode,
+				Pixel:    p,
+				Value:    img[p],
+				Parent:   -1,
+				Children: []int{h},
+				Death:    -1,
+			})
+			mt.nodes[h].Parent = len(mt.nodes) - 1
+		}
+	}
+}
+
+// Nodes returns the nodes of the merge tree.
+func (mt *MergeTree) Nodes() []MergeNode {
+	return mt.nodes
+}
+
+// Root returns the index of the root node, which is located at the
+// global minimum of the image.
+func (mt *MergeTree) Root() int {
+	for k, nd := range mt.nodes {
+		if nd.Parent == -1 {
+			return k
+		}
+	}
+	return -1
+}
+
+// Owner returns, for each pixel, the index of the maximum node at
+// which the branch containing the pixel begins.  A pixel belongs to
+// the oldest branch of the component that it joins when the
+// threshold falls to its intensity.
+func (mt *MergeTree) Owner() []int {
+	return mt.owner
+}
+
+// BirthDeath returns the object birth and death times, in the same
+// form as Persistence.BirthDeath.  Each branch of the merge tree
+// corresponds to an object, which is present for thresholds from the
+// least pixel intensity greater than the value at which the branch
+// merges into an older branch, up to the value at its maximum.  The
+// oldest branch is present for all thresholds.  Branches that merge
+// at the same value as their maximum are never present as separate
+// objects and are omitted.  The results agree with
+// NewExactPersistence.
+func (mt *MergeTree) BirthDeath() ([]float64, []float64) {
+
+	var birth, death []float64
+
+	for _, nd := range mt.nodes {
+		if nd.Type != MaxNode {
+			continue
+		}
+		if nd.Death == -1 {
+			birth = append(birth, float64(mt.levels[0]))
+			death = append(death, float64(nd.Value))
+			continue
+		}
+		s := mt.nodes[nd.Death].Value
+		if s == nd.Value {
+			continue
+		}
+		k := sort.SearchInts(mt.levels, s)
+		birth = append(birth, float64(mt.levels[k+1]))
+		death = append(death, float64(nd.Value))
+	}
+
+	return birth, death
+}
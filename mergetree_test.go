@@ -0,0 +1,135 @@
+package tda
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestMergeTree(t *testing.T) {
+
+	img := []int{
+		0, 0, 0, 0, 0, 0, 0,
+		0, 9, 4, 7, 3, 8, 0,
+		0, 0, 0, 0, 0, 0, 0,
+	}
+
+	mt := NewMergeTree(img, 3)
+	nodes := mt.Nodes()
+
+	// The maxima are found first in order of decreasing value,
+	// followed by the saddles and the root.
+	if len(nodes) != 6 {
+		fmt.Printf("Found %d nodes, expected 6\n%+v\n", len(nodes), nodes)
+		t.FailNow()
+	}
+
+	expected := []MergeNode{
+		{Type: MaxNode, Pixel: 8, Value: 9},
+		{Type: MaxNode, Pixel: 12, Value: 8},
+		{Type: MaxNode, Pixel: 10, Value: 7},
+	}
+
+	// Check the structure of the tree
+	if nodes[3].Type != SaddleNode || nodes[3].Value != 4 || nodes[4].Type != SaddleNode ||
+		nodes[4].Value != 3 || nodes[5].Type != MinNode || mt.Root() != 5 {
+		fmt.Printf("Unexpected nodes:\n%+v\n", nodes)
+		t.Fail()
+	}
+	for k := 0; k < 3; k++ {
+		e := expected[k]
+		nd := nodes[k]
+		if nd.Type != e.Type || nd.Pixel != e.Pixel || nd.Value != e.Value {
+			fmt.Printf("Node %d is %+v, expected %+v\n", k, nd, e)
+			t.Fail()
+		}
+	}
+	if nodes[0].Parent != 3 || nodes[2].Parent != 3 || nodes[3].Parent != 4 ||
+		nodes[1].Parent != 4 || nodes[4].Parent != 5 {
+		fmt.Printf("Unexpected parent links:\n%+v\n", nodes)
+		t.Fail()
+	}
+	if nodes[0].Death != -1 || nodes[1].Death != 4 || nodes[2].Death != 3 {
+		fmt.Printf("Unexpected death nodes:\n%+v\n", nodes)
+		t.Fail()
+	}
+
+	owner := mt.Owner()
+	eowner := []int{
+		0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 2, 0, 1, 0,
+		0, 0, 0, 0, 0, 0, 0,
+	}
+	if !compareLabels(owner, eowner) {
+		fmt.Printf("Owners do not match\nGot:\n")
+		lprint(owner, 3)
+		t.Fail()
+	}
+
+	birth, death := mt.BirthDeath()
+	if !floats.Equal(birth, []float64{0, 4, 7}) || !floats.Equal(death, []float64{9, 8, 7}) {
+		fmt.Printf("Unexpected birth/death times %v %v\n", birth, death)
+		t.Fail()
+	}
+}
+
+// The birth and death times from the merge tree should agree with the
+// exact persistence trajectories.
+func TestMergeTreeBirthDeath(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(44))
+
+	for jt := 0; jt < 20; jt++ {
+
+		rows, cols := 3+rng.Intn(10), 3+rng.Intn(10)
+		img := make([]int, rows*cols)
+		for i := range img {
+			img[i] = rng.Intn(10)
+		}
+
+		b1, d1 := NewMergeTree(img, rows).BirthDeath()
+		b2, d2 := NewExactPersistence(img, rows).BirthDeath()
+
+		if !comparePairs(b1, d1, b2, d2) {
+			fmt.Printf("Merge tree and exact persistence disagree in test %d\n", jt)
+			fmt.Printf("%v %v\n%v %v\n", b1, d1, b2, d2)
+			t.Fail()
+		}
+	}
+}
+
+// comparePairs returns true if the two sets of birth/death pairs are
+// equal up to ordering.
+func comparePairs(b1, d1, b2, d2 []float64) bool {
+
+	if len(b1) != len(b2) {
+		return false
+	}
+
+	p1 := make([][2]float64, len(b1))
+	p2 := make([][2]float64, len(b2))
+	for i := range b1 {
+		p1[i] = [2]float64{b1[i], d1[i]}
+		p2[i] = [2]float64{b2[i], d2[i]}
+	}
+
+	for _, p := range [][][2]float64{p1, p2} {
+		sort.Slice(p, func(i, j int) bool {
+			if p[i][0] != p[j][0] {
+				return p[i][0] < p[j][0]
+			}
+			return p[i][1] < p[j][1]
+		})
+	}
+
+	for i := range p1 {
+		if p1[i] != p2[i] {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,231 @@
+package tda
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+)
+
+// Diagram is a persistence diagram, given by the birth and death
+// times of its points, e.g. as returned by BirthDeath.
+type Diagram struct {
+	Birth []float64
+	Death []float64
+}
+
+// TwoSampleStatistic defines a statistic that measures the difference
+// between two groups of persistence diagrams, with larger values
+// indicating a greater difference.  The function is called once with
+// the pooled diagrams, so that quantities that do not depend on the
+// grouping can be computed in advance.  It returns a function that
+// computes the statistic for a grouping of the pooled diagrams, in
+// which first[i] is true if diagram i belongs to the first group.
+// The returned function may be called concurrently from several
+// goroutines.
+type TwoSampleStatistic func(diagrams []Diagram) func(first []bool) float64
+
+// LandscapeStatistic returns a statistic that is the Lp distance
+// between the mean landscapes of the two groups, see
+// LandscapeDistance.  Use p = 2 for the L2 distance.
+func LandscapeStatistic(p float64) TwoSampleStatistic {
+	return func(diagrams []Diagram) func([]bool) float64 {
+		ls := make([]*Landscape, len(diagrams))
+		for i, dg := range diagrams {
+			ls[i] = NewLandscape(dg.Birth, dg.Death)
+		}
+		return func(first []bool) float64 {
+			var g1, g2 []*Landscape
+			for i, f := range first {
+				if f {
+					g1 = append(g1, ls[i])
+				} else {
+					g2 = append(g2, ls[i])
+				}
+			}
+			return LandscapeDistance(MeanLandscape(g1), MeanLandscape(g2), p)
+		}
+	}
+}
+
+// ConvexPeelStatistic returns a statistic based on the convex peels of
+// the diagrams, viewed as point sets.  The area, perimeter and
+// centroid of the peel of each diagram at the given depths (which
+// must be decreasing, see ConvexPeel.Stats) are collected into a
+// feature vector, and the statistic is the squared Euclidean distance
+// between the mean feature vectors of the two groups.
+func ConvexPeelStatistic(depth []float64) TwoSampleStatistic {
+	return func(diagrams []Diagram) func([]bool) float64 {
+		feat := make([][]float64, len(diagrams))
+		for i, dg := range diagrams {
+			for _, st := range NewConvexPeel(dg.Birth, dg.Death).Stats(depth) {
+				feat[i] = append(feat[i], st.Area, st.Perimeter, st.Centroid[0], st.Centroid[1])
+			}
+		}
+		return func(first []bool) float64 {
+			m := len(feat[0])
+			m1 := make([]float64, m)
+			m2 := make([]float64, m)
+			var n1, n2 float64
+			for _, f := range first {
+				if f {
+					n1++
+				} else {
+					n2++
+				}
+			}
+			for i, f := range first {
+				for k, x := range feat[i] {
+					if f {
+						m1[k] += x / n1
+					} else {
+						m2[k] += x / n2
+					}
+				}
+			}
+			var s float64
+			for k := range m1 {
+				s += (m1[k] - m2[k]) * (m1[k] - m2[k])
+			}
+			return s
+		}
+	}
+}
+
+// DiagramDistanceStatistic returns a statistic based on a distance
+// between diagrams, such as the bottleneck or Wasserstein distance.
+// The statistic is the mean distance between diagrams in different
+// groups, minus the average of the mean distances between distinct
+// diagrams in the same group (an energy statistic).  The distances
+// between all pairs of diagrams are computed once.
+func DiagramDistanceStatistic(dist func(birth1, death1, birth2, death2 []float64) float64) TwoSampleStatistic {
+	return func(diagrams []Diagram) func([]bool) float64 {
+		n := len(diagrams)
+		d := make([][]float64, n)
+		for i := range d {
+			d[i] = make([]float64, n)
+		}
+		for i := 0; i < n; i++ {
+			for j := 0; j < i; j++ {
+				x := dist(diagrams[i].Birth, diagrams[i].Death, diagrams[j].Birth, diagrams[j].Death)
+				d[i][j] = x
+				d[j][i] = x
+			}
+		}
+		return func(first []bool) float64 {
+			var b, w1, w2, nb, nw1, nw2 float64
+			for i := 0; i < n; i++ {
+				for j := 0; j < i; j++ {
+					switch {
+					case first[i] != first[j]:
+						b += d[i][j]
+						nb++
+					case first[i]:
+						w1 += d[i][j]
+						nw1++
+					default:
+						w2 += d[i][j]
+						nw2++
+					}
+				}
+			}
+			s := b / nb
+			if nw1 > 0 {
+				s -= w1 / nw1 / 2
+			}
+			if nw2 > 0 {
+				s -= w2 / nw2 / 2
+			}
+			return s
+		}
+	}
+}
+
+// PermutationOptions contains optional settings for PermutationTest.
+// The zero value gives the default settings.
+type PermutationOptions struct {
+
+	// The number of random permutations, the default is 1000.
+	NumPerm int
+
+	// The number of goroutines used to compute the statistic for
+	// the permuted groups.  If zero or one, a single goroutine is
+	// used.
+	Workers int
+
+	// The seed for the random number generator used to permute
+	// the groups.  The results depend only on the seed, not on the
+	// number of goroutines.
+	Seed int64
+}
+
+// PermutationTest performs a permutation test of the null hypothesis
+// that two groups of persistence diagrams come from the same
+// distribution, using the given statistic, e.g. LandscapeStatistic,
+// ConvexPeelStatistic or DiagramDistanceStatistic.  The group labels
+// of the pooled diagrams are randomly permuted, and the p-value is the
+// proportion of the permutations, counting the observed grouping, for
+// which the statistic is at least as large as the observed statistic.
+// The observed statistic and the p-value are returned.
+func PermutationTest(g1, g2 []Diagram, stat TwoSampleStatistic, opts PermutationOptions) (float64, float64) {
+
+	if len(g1) == 0 || len(g2) == 0 {
+		panic("both groups must contain diagrams")
+	}
+
+	nperm := opts.NumPerm
+	if nperm == 0 {
+		nperm = 1000
+	}
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	n := len(g1) + len(g2)
+	pooled := make([]Diagram, 0, n)
+	pooled = append(pooled, g1...)
+	pooled = append(pooled, g2...)
+	f := stat(pooled)
+
+	first := make([]bool, n)
+	for i := range g1 {
+		first[i] = true
+	}
+	obs := f(first)
+
+	// Draw all of the permutations in advance, so that they do not
+	// depend on the scheduling of the goroutines.
+	rng := rand.New(rand.NewSource(opts.Seed))
+	perms := make([][]bool, nperm)
+	for k := range perms {
+		p := make([]bool, n)
+		copy(p, first)
+		rng.Shuffle(n, func(i, j int) { p[i], p[j] = p[j], p[i] })
+		perms[k] = p
+	}
+
+	vals := make([]float64, nperm)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for k := w; k < nperm; k += workers {
+				vals[k] = f(perms[k])
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	// Allow for rounding error when a permutation reproduces the
+	// observed grouping.
+	tol := 1e-10 * math.Max(1, math.Abs(obs))
+	count := 1
+	for _, v := range vals {
+		if v >= obs-tol {
+			count++
+		}
+	}
+
+	return obs, float64(count) / float64(nperm+1)
+}
@@ -0,0 +1,67 @@
+package tda
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// randomDiagrams returns diagrams whose points have persistence
+// uniformly distributed on [0, scale].
+func randomDiagrams(rng *rand.Rand, n int, scale float64) []Diagram {
+	var dg []Diagram
+	for i := 0; i < n; i++ {
+		m := 15 + rng.Intn(10)
+		b := make([]float64, m)
+		d := make([]float64, m)
+		for k := range b {
+			b[k] = 10 * rng.Float64()
+			d[k] = b[k] + scale*rng.Float64()
+		}
+		dg = append(dg, Diagram{Birth: b, Death: d})
+	}
+	return dg
+}
+
+func TestPermutationTest(t *testing.T) {
+
+	bottleneck := func(b1, d1, b2, d2 []float64) float64 {
+		r, _ := Bottleneck(b1, d1, b2, d2)
+		return r
+	}
+
+	stats := []TwoSampleStatistic{
+		LandscapeStatistic(2),
+		ConvexPeelStatistic([]float64{0.9, 0.5}),
+		DiagramDistanceStatistic(bottleneck),
+	}
+
+	rng := rand.New(rand.NewSource(161))
+
+	for js, stat := range stats {
+
+		// Groups from the same distribution
+		g1 := randomDiagrams(rng, 8, 3)
+		g2 := randomDiagrams(rng, 8, 3)
+		_, p := PermutationTest(g1, g2, stat, PermutationOptions{NumPerm: 200, Seed: 1})
+		if p < 0.01 {
+			fmt.Printf("Statistic %d: p-value %v for groups from the same distribution\n", js, p)
+			t.Fail()
+		}
+
+		// Groups from different distributions
+		g2 = randomDiagrams(rng, 8, 6)
+		obs, p := PermutationTest(g1, g2, stat, PermutationOptions{NumPerm: 200, Seed: 1})
+		if p > 0.01 {
+			fmt.Printf("Statistic %d: p-value %v for groups from different distributions\n", js, p)
+			t.Fail()
+		}
+
+		// The results do not depend on the number of goroutines.
+		obs2, p2 := PermutationTest(g1, g2, stat, PermutationOptions{NumPerm: 200, Seed: 1, Workers: 4})
+		if obs != obs2 || p != p2 {
+			fmt.Printf("Statistic %d: results depend on the number of goroutines\n", js)
+			t.Fail()
+		}
+	}
+}
@@ -13,6 +13,13 @@ type Persistence struct {
 	rows int
 	cols int
 
+	// The connectivity used to label the thresholded images
+	conn int
+
+	// The number of goroutines used to label the thresholded
+	// images
+	workers int
+
 	// The current step, 1 plus the number of times that Next was
 	// called.
 	step int
@@ -44,6 +51,67 @@ type Persistence struct {
 	// Link each region in the previous image to its descendent in the
 	// current image
 	pns []Pstate
+
+	// The label of the descendent of each region in the previous
+	// image
+	desc []int
+
+	// The region in the previous image that contains each region
+	// in the current image
+	anc []int
+
+	// The origin of each trajectory, aligned with traj
+	branches []Branch
+
+	// The split events
+	events []Event
+}
+
+// Branch describes the origin of a persistence trajectory.
+type Branch struct {
+
+	// The index of the trajectory from which this trajectory
+	// branched off, or -1 if the trajectory began at the first
+	// step.
+	Parent int
+
+	// The first step of the trajectory.  At the preceding step,
+	// the object described by the trajectory was part of the
+	// object described by the parent trajectory.
+	Step int
+}
+
+// Event describes the splitting of an object into several objects
+// as the threshold increases.  Viewed in the direction of decreasing
+// threshold, this is a merge of several objects into one object.
+type Event struct {
+
+	// The step at which the object has split, and its threshold.
+	Step      int
+	Threshold int
+
+	// The index of the trajectory that continues through the
+	// split, following the brightest of the pieces.
+	Parent int
+
+	// The indices of the trajectories that begin at this step,
+	// following the remaining pieces.
+	Children []int
+}
+
+// Branches returns the origin of every persistence trajectory.  The
+// branch in position i describes the trajectory in position i of the
+// slice returned by Trajectories.  The parent links define a lineage
+// tree (or forest) of the objects across thresholds.
+func (ps *Persistence) Branches() []Branch {
+	return ps.branches
+}
+
+// Events returns the split events (merge events in the direction of
+// decreasing threshold), in order of increasing step.  The trajectory
+// indices refer to positions in the slice returned by Trajectories.
+func (ps *Persistence) Events() []Event {
+	return ps.events
 }
 
 // Trajectories returns the persistence trajectories.  Each outer
@@ -82,17 +150,67 @@ type Pstate struct {
 // BirthDeath returns the object birth and death times as float64
 // slices.
 func (ps *Persistence) BirthDeath() ([]float64, []float64) {
+	return birthDeath(len(ps.traj), func(i int) (int, int) {
+		tr := ps.traj[i]
+		return tr[0].Threshold, tr[len(tr)-1].Threshold
+	})
+}
+
+// birthDeath returns the thresholds of the first and last states of
+// each of n trajectories as float64 slices, where ends returns the
+// thresholds for one trajectory.
+func birthDeath(n int, ends func(i int) (int, int)) ([]float64, []float64) {
 
 	var birth, death []float64
 
-	for _, tr := range ps.traj {
-		birth = append(birth, float64(tr[0].Threshold))
-		death = append(death, float64(tr[len(tr)-1].Threshold))
+	for i := 0; i < n; i++ {
+		b, d := ends(i)
+		birth = append(birth, float64(b))
+		death = append(death, float64(d))
 	}
 
 	return birth, death
 }
 
+// stepThresholds returns the thresholds used at the steps after the
+// first, which divide the range from mn to mx into steps-1 increments.
+// If there is only one step, nil is returned.
+func stepThresholds(mn, mx, steps int) []int {
+
+	if steps < 2 {
+		return nil
+	}
+
+	thresh := make([]int, 0, steps-1)
+	d := float64(mx-mn) / float64(steps-1)
+	for i := 1; i < steps; i++ {
+		thresh = append(thresh, mn+int(float64(i)*d))
+	}
+
+	return thresh
+}
+
+// favored returns true if a region with maximum intensity m and size
+// s is favored as the descendent of an object over a region with
+// maximum intensity m0 and size s0.  The favored descendent is the
+// brightest one, which will have the longest lifespan.  But if the
+// brightness values are tied, go with the larger region.
+func favored(m, s, m0, s0 int) bool {
+	return m > m0 || (m == m0 && s > s0)
+}
+
+// stateLess orders the states of objects by maximum intensity, then
+// by size, then by label.
+func stateLess(m1, s1, l1, m2, s2, l2 int) bool {
+	if m1 != m2 {
+		return m1 < m2
+	}
+	if s1 != s2 {
+		return s1 < s2
+	}
+	return l1 < l2
+}
+
 func threshold(img []int, timg []uint8, thresh int) []uint8 {
 
 	if len(timg) != len(img) {
@@ -131,11 +249,40 @@ func maxes(lab, max2, img []int, ncomp, rows int) []int {
 	return max2
 }
 
+// PersistenceOptions contains optional settings for
+// NewPersistenceOpts.  The zero value gives the settings used by
+// NewPersistence.
+type PersistenceOptions struct {
+
+	// The connectivity (4 or 8) used to define the objects in
+	// each thresholded image, see NewLabelConn.  If zero, 8 is
+	// used.
+	Conn int
+
+	// The number of goroutines used to label each thresholded
+	// image, see NewLabelParallel.  If zero or one, the images are
+	// labeled with a single goroutine.
+	Workers int
+}
+
 // NewPersistence calculates an object persistence diagram for the
 // given image, which must be rectangular with the given number of
 // rows.  The steps argument determines the threshold increments used
-// to produce the persistence diagram.
+// to produce the persistence diagram.  The thresholded images are
+// labeled with NewLabelPreserve, so objects touching the image border
+// are not truncated.
 func NewPersistence(img []int, rows, steps int) *Persistence {
+	return NewPersistenceOpts(img, rows, steps, PersistenceOptions{})
+}
+
+// NewPersistenceOpts calculates an object persistence diagram as in
+// NewPersistence, using the given options.
+func NewPersistenceOpts(img []int, rows, steps int, opts PersistenceOptions) *Persistence {
+
+	conn := opts.Conn
+	if conn == 0 {
+		conn = 8
+	}
 
 	cols := len(img) / rows
 	if rows*cols != len(img) {
@@ -151,7 +298,7 @@ func NewPersistence(img []int, rows, steps int) *Persistence {
 	lbuf2 := make([]int, rows*cols)
 
 	// Label the first image
-	lbl := NewLabel(timg, rows, lbuf2)
+	lbl := labelImage(timg, rows, conn, opts.Workers, lbuf2)
 	lbuf2 = lbl.Labels()
 	size2 := lbl.Sizes(nil)
 	max2 := maxes(lbuf2, nil, img, len(size2), rows)
@@ -159,6 +306,7 @@ func NewPersistence(img []int, rows, steps int) *Persistence {
 
 	// Start the persistence trajectories
 	var traj []Trajectory
+	var branches []Branch
 	for k, m := range max2 {
 		if k != 0 {
 			s := size2[k]
@@ -174,28 +322,30 @@ func NewPersistence(img []int, rows, steps int) *Persistence {
 				},
 			}
 			traj = append(traj, v)
+			branches = append(branches, Branch{Parent: -1})
 		}
 	}
 
 	per := &Persistence{
-		rows:    rows,
-		cols:    cols,
-		img:     img,
-		timg:    timg,
-		lbuf1:   lbuf1,
-		lbuf2:   lbuf2,
-		traj:    traj,
-		size2:   size2,
-		max2:    max2,
-		bboxes2: bboxes2,
-		min:     mn,
-		max:     mx,
+		rows:     rows,
+		cols:     cols,
+		conn:     conn,
+		workers:  opts.Workers,
+		img:      img,
+		timg:     timg,
+		lbuf1:    lbuf1,
+		lbuf2:    lbuf2,
+		traj:     traj,
+		branches: branches,
+		size2:    size2,
+		max2:     max2,
+		bboxes2:  bboxes2,
+		min:      mn,
+		max:      mx,
 	}
 
 	// Extend the persistence trajectories
-	d := float64(mx-mn) / float64(steps-1)
-	for i := 1; i < steps; i++ {
-		t := mn + int(float64(i)*d)
+	for _, t := range stepThresholds(mn, mx, steps) {
 		per.next(t)
 	}
 
@@ -209,38 +359,60 @@ func (ps *Persistence) Labels() []int {
 
 func (ps *Persistence) getAncestors(thresh int) {
 
-	ps.pns = ps.pns[0:0]
-
-	rc := ps.rows * ps.cols
-	for i := 0; i < rc; i++ {
-		if ps.lbuf1[i] == 0 || ps.lbuf2[i] == 0 {
-			continue
-		}
-		l1 := ps.lbuf1[i]
-		l2 := ps.lbuf2[i]
-		s2 := ps.size2[l2]
-		m2 := ps.max2[l2]
-		for len(ps.pns) < l1+1 {
-			ps.pns = append(ps.pns, Pstate{})
-		}
-		mx := ps.pns[l1].Max
+	ps.desc, ps.anc = overlaps(ps.lbuf1, ps.lbuf2, ps.size2, ps.max2, ps.desc, ps.anc)
 
-		// The favored descendent is the brightest one, which
-		// will have the longest lifespan.  But if the
-		// brightness values are tied, go with the larger
-		// region.
-		if m2 > mx || (m2 == mx && s2 > ps.pns[l1].Size) {
-			bb := ps.bboxes2[l2]
-			ps.pns[l1] = Pstate{
+	ps.pns = ps.pns[0:0]
+	for _, l2 := range ps.desc {
+		var q Pstate
+		if l2 != 0 {
+			q = Pstate{
 				Label:     l2,
-				Max:       m2,
-				Size:      s2,
+				Max:       ps.max2[l2],
+				Size:      ps.size2[l2],
 				Step:      ps.step,
 				Threshold: thresh,
-				Bbox:      bb,
+				Bbox:      ps.bboxes2[l2],
 			}
 		}
+		ps.pns = append(ps.pns, q)
+	}
+}
+
+// overlaps links the regions of two successive labelings, with the
+// sizes and maximum intensities of the current regions.  For each
+// region of the previous labeling, the first returned slice holds the
+// favored region of the current labeling that overlaps it (see
+// favored), or 0 if there is none.  For each region of the current
+// labeling, the second returned slice holds the region of the
+// previous labeling that contains it, or 0.  The provided buffers are
+// used if large enough.
+func overlaps(lbuf1, lbuf2, size2, max2, desc, anc []int) ([]int, []int) {
+
+	desc = desc[0:0]
+	anc = anc[0:0]
+
+	for i := range lbuf2 {
+		l1, l2 := lbuf1[i], lbuf2[i]
+		if l1 == 0 || l2 == 0 {
+			continue
+		}
+		for len(anc) < l2+1 {
+			anc = append(anc, 0)
+		}
+		anc[l2] = l1
+		for len(desc) < l1+1 {
+			desc = append(desc, 0)
+		}
+		var m0, s0 int
+		if d := desc[l1]; d != 0 {
+			m0, s0 = max2[d], size2[d]
+		}
+		if favored(max2[l2], size2[l2], m0, s0) {
+			desc[l1] = l2
+		}
 	}
+
+	return desc, anc
 }
 
 // Extend each region from the previous step to its descendant in the
@@ -249,11 +421,19 @@ func (ps *Persistence) getAncestors(thresh int) {
 func (ps *Persistence) extend(thresh int) {
 
 	notnew := make([]bool, 0, 1000)
+
+	// The trajectory containing each region of the previous step
+	prev := make([]int, 0, 1000)
+
 	for i, tr := range ps.traj {
 		r := tr[len(tr)-1]
 		if r.Step != ps.step-1 {
 			continue
 		}
+		for len(prev) < r.Label+1 {
+			prev = append(prev, -1)
+		}
+		prev[r.Label] = i
 		for len(ps.pns) < r.Label+1 {
 			ps.pns = append(ps.pns, Pstate{})
 		}
@@ -267,6 +447,10 @@ func (ps *Persistence) extend(thresh int) {
 		}
 	}
 
+	// The split event for each region of the previous step, if
+	// any
+	ev := make(map[int]int)
+
 	for l2, m2 := range ps.max2 {
 		for len(notnew) < l2+1 {
 			notnew = append(notnew, false)
@@ -285,10 +469,30 @@ func (ps *Persistence) extend(thresh int) {
 				},
 			}
 			ps.traj = append(ps.traj, v)
+
+			// The region has split off from its ancestor
+			pt := prev[ps.anc[l2]]
+			ps.branches = append(ps.branches, Branch{Parent: pt, Step: ps.step})
+			k, ok := ev[pt]
+			if !ok {
+				k = len(ps.events)
+				ev[pt] = k
+				ps.events = append(ps.events, Event{Step: ps.step, Threshold: thresh, Parent: pt})
+			}
+			ps.events[k].Children = append(ps.events[k].Children, len(ps.traj)-1)
 		}
 	}
 }
 
+// labelImage labels a thresholded image, using NewLabelParallel if more
+// than one worker is requested.
+func labelImage(timg []uint8, rows, conn, workers int, buf []int) *Label {
+	if workers > 1 {
+		return NewLabelParallel(timg, rows, conn, workers, buf)
+	}
+	return NewLabelPreserve(timg, rows, conn, buf)
+}
+
 // next adds another labeled image to the persistence graph.  The
 // threshold values t should be strictly increasing.
 func (ps *Persistence) next(t int) {
@@ -298,7 +502,7 @@ func (ps *Persistence) next(t int) {
 	ps.step++
 	ps.timg = threshold(ps.img, ps.timg, t)
 
-	lbl := NewLabel(ps.timg, ps.rows, ps.lbuf2)
+	lbl := labelImage(ps.timg, ps.rows, ps.conn, ps.workers, ps.lbuf2)
 	ps.lbuf2 = lbl.Labels()
 	ps.size2 = lbl.Sizes(ps.size2)
 	ps.max2 = maxes(ps.lbuf2, ps.max2, ps.img, len(ps.size2), ps.rows)
@@ -317,20 +521,61 @@ type straj []Trajectory
 func (a straj) Len() int      { return len(a) }
 func (a straj) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
 func (a straj) Less(i, j int) bool {
-	if a[i][0].Max < a[j][0].Max {
-		return true
-	} else if a[i][0].Max > a[j][0].Max {
-		return false
-	}
-	if a[i][0].Size < a[j][0].Size {
-		return true
-	} else if a[i][0].Size > a[j][0].Size {
-		return false
-	}
-	return a[i][0].Label < a[j][0].Label
+	p, q := a[i][0], a[j][0]
+	return stateLess(p.Max, p.Size, p.Label, q.Max, q.Size, q.Label)
+}
+
+// ptraj sorts trajectories while tracking their original positions.
+type ptraj struct {
+	straj
+	perm []int
+}
+
+func (a ptraj) Swap(i, j int) {
+	a.straj.Swap(i, j)
+	a.perm[i], a.perm[j] = a.perm[j], a.perm[i]
 }
 
 // Sort gives a deterministic order to the persistence trajectories.
+// The trajectory indices in the branches and events are updated to
+// refer to the new positions.
 func (ps *Persistence) Sort() {
-	sort.Sort(sort.Reverse(straj(ps.traj)))
+
+	perm := make([]int, len(ps.traj))
+	for i := range perm {
+		perm[i] = i
+	}
+	sort.Sort(sort.Reverse(ptraj{straj(ps.traj), perm}))
+
+	if ps.branches == nil {
+		return
+	}
+
+	// inv maps old positions to new positions
+	inv := make([]int, len(perm))
+	for i, j := range perm {
+		inv[j] = i
+	}
+	remap := func(i int) int {
+		if i < 0 {
+			return i
+		}
+		return inv[i]
+	}
+
+	br := make([]Branch, len(ps.branches))
+	for i, j := range perm {
+		br[i] = ps.branches[j]
+		br[i].Parent = remap(br[i].Parent)
+	}
+	ps.branches = br
+
+	for k := range ps.events {
+		e := &ps.events[k]
+		e.Parent = remap(e.Parent)
+		for i := range e.Children {
+			e.Children[i] = remap(e.Children[i])
+		}
+		sort.Ints(e.Children)
+	}
 }
@@ -0,0 +1,271 @@
+package tda
+
+import (
+	"sort"
+)
+
+// Persistence3D constructs object persistence trajectories for a
+// volume.  The trajectories are linked across thresholds as in
+// Persistence, but the branches and split events of the trajectories
+// are not recorded.
+type Persistence3D struct {
+
+	// The dimensions of the volume
+	rows   int
+	cols   int
+	slices int
+
+	// The connectivity used to label the thresholded volumes
+	conn int
+
+	// The current step, 1 plus the number of times that next was
+	// called.
+	step int
+
+	// The persistence trajectories
+	traj []Trajectory3D
+
+	// The original volume being processed
+	img []int
+
+	// The minimum and maximum of the voxel intensities
+	min, max int
+
+	// The current thresholded volume
+	timg []uint8
+
+	// The current and previous labeled volume
+	lbuf1, lbuf2 []int
+
+	// The current distribution of sizes
+	size2 []int
+
+	// The current distribution of maximum intensities
+	max2 []int
+
+	// The current set of bounding boxes
+	bboxes2 []Box3
+
+	// Link each region in the previous volume to its descendent in
+	// the current volume
+	pns []Pstate3D
+
+	// The label of the descendent of each region in the previous
+	// volume
+	desc []int
+}
+
+// Pstate3D defines a state in a persistence trajectory for a volume.
+type Pstate3D struct {
+
+	// The connected component label for the object (not
+	// comparable across points on a trajectory).
+	Label int
+
+	// The size in voxels of the object.
+	Size int
+
+	// The maximum intensity of the object.
+	Max int
+
+	// The step of the algorithm at which the state is defined.
+	Step int
+
+	// The threshold used to define the volume used at this step
+	// of the algorithm.
+	Threshold int
+
+	// A bounding box for the object
+	Bbox Box3
+}
+
+// Trajectory3D is a sequence of persistence states defined by
+// labeling a volume thresholded at an increasing sequence of
+// threshold values.
+type Trajectory3D []Pstate3D
+
+// NewPersistence3D calculates an object persistence diagram for the
+// given volume, which is a stack of slices having the given number of
+// rows and columns, stored as described in NewLabel3D.  The steps
+// argument determines the threshold increments used to produce the
+// persistence diagram, and conn is the connectivity (6, 18 or 26)
+// used to define the objects.
+func NewPersistence3D(img []int, rows, cols, steps, conn int) *Persistence3D {
+
+	slices := len(img) / (rows * cols)
+	if rows*cols*slices != len(img) {
+		panic("rows and cols are not compatible with img")
+	}
+
+	mn, mx := iminmax(img)
+	n := len(img)
+
+	timg := make([]uint8, n)
+	timg = threshold(img, timg, mn)
+
+	lbuf1 := make([]int, n)
+	lbuf2 := make([]int, n)
+
+	// Label the first volume
+	lbl := NewLabel3D(timg, rows, cols, conn, lbuf2)
+	lbuf2 = lbl.Labels()
+	size2 := lbl.Sizes(nil)
+	max2 := maxes(lbuf2, nil, img, len(size2), rows)
+	bboxes2 := lbl.Bboxes(nil)
+
+	// Start the persistence trajectories
+	var traj []Trajectory3D
+	for k, m := range max2 {
+		if k != 0 {
+			v := []Pstate3D{
+				{
+					Label:     k,
+					Max:       m,
+					Size:      size2[k],
+					Step:      0,
+					Threshold: mn,
+					Bbox:      bboxes2[k],
+				},
+			}
+			traj = append(traj, v)
+		}
+	}
+
+	per := &Persistence3D{
+		rows:    rows,
+		cols:    cols,
+		slices:  slices,
+		conn:    conn,
+		img:     img,
+		timg:    timg,
+		lbuf1:   lbuf1,
+		lbuf2:   lbuf2,
+		traj:    traj,
+		size2:   size2,
+		max2:    max2,
+		bboxes2: bboxes2,
+		min:     mn,
+		max:     mx,
+	}
+
+	// Extend the persistence trajectories
+	for _, t := range stepThresholds(mn, mx, steps) {
+		per.next(t)
+	}
+
+	return per
+}
+
+// Trajectories returns the persistence trajectories.  Each outer
+// element of the returned slice is a sequence of states defining a
+// trajectory.  Calling Sort before calling Trajectories ensures a
+// deterministic order.
+func (ps *Persistence3D) Trajectories() []Trajectory3D {
+	return ps.traj
+}
+
+// Labels returns the current object labels.
+func (ps *Persistence3D) Labels() []int {
+	return ps.lbuf2
+}
+
+// BirthDeath returns the object birth and death times as float64
+// slices.
+func (ps *Persistence3D) BirthDeath() ([]float64, []float64) {
+	return birthDeath(len(ps.traj), func(i int) (int, int) {
+		tr := ps.traj[i]
+		return tr[0].Threshold, tr[len(tr)-1].Threshold
+	})
+}
+
+func (ps *Persistence3D) getAncestors(thresh int) {
+
+	ps.desc, _ = overlaps(ps.lbuf1, ps.lbuf2, ps.size2, ps.max2, ps.desc, nil)
+
+	ps.pns = ps.pns[0:0]
+	for _, l2 := range ps.desc {
+		var q Pstate3D
+		if l2 != 0 {
+			q = Pstate3D{
+				Label:     l2,
+				Max:       ps.max2[l2],
+				Size:      ps.size2[l2],
+				Step:      ps.step,
+				Threshold: thresh,
+				Bbox:      ps.bboxes2[l2],
+			}
+		}
+		ps.pns = append(ps.pns, q)
+	}
+}
+
+// Extend each region from the previous step to its descendant in the
+// current step, where possible, and add regions that are born in
+// this step.
+func (ps *Persistence3D) extend(thresh int) {
+
+	notnew := make([]bool, len(ps.max2))
+	for i, tr := range ps.traj {
+		r := tr[len(tr)-1]
+		if r.Step != ps.step-1 {
+			continue
+		}
+		for len(ps.pns) < r.Label+1 {
+			ps.pns = append(ps.pns, Pstate3D{})
+		}
+		q := ps.pns[r.Label]
+		if q.Size > 0 {
+			ps.traj[i] = append(ps.traj[i], q)
+			notnew[q.Label] = true
+		}
+	}
+
+	for l2, m2 := range ps.max2 {
+		if l2 != 0 && !notnew[l2] {
+			v := []Pstate3D{
+				{
+					Label:     l2,
+					Max:       m2,
+					Size:      ps.size2[l2],
+					Step:      ps.step,
+					Threshold: thresh,
+					Bbox:      ps.bboxes2[l2],
+				},
+			}
+			ps.traj = append(ps.traj, v)
+		}
+	}
+}
+
+// next adds another labeled volume to the persistence graph.  The
+// threshold values t should be strictly increasing.
+func (ps *Persistence3D) next(t int) {
+
+	ps.lbuf1, ps.lbuf2 = ps.lbuf2, ps.lbuf1
+
+	ps.step++
+	ps.timg = threshold(ps.img, ps.timg, t)
+
+	lbl := NewLabel3D(ps.timg, ps.rows, ps.cols, ps.conn, ps.lbuf2)
+	ps.lbuf2 = lbl.Labels()
+	ps.size2 = lbl.Sizes(ps.size2)
+	ps.max2 = maxes(ps.lbuf2, ps.max2, ps.img, len(ps.size2), ps.rows)
+	ps.bboxes2 = lbl.Bboxes(ps.bboxes2)
+
+	ps.getAncestors(t)
+	ps.extend(t)
+}
+
+type straj3 []Trajectory3D
+
+func (a straj3) Len() int      { return len(a) }
+func (a straj3) Swap(i, j int) { a[i], a[j] = a[j], a[i] }
+func (a straj3) Less(i, j int) bool {
+	p, q := a[i][0], a[j][0]
+	return stateLess(p.Max, p.Size, p.Label, q.Max, q.Size, q.Label)
+}
+
+// Sort gives a deterministic order to the persistence trajectories.
+func (ps *Persistence3D) Sort() {
+	sort.Sort(sort.Reverse(straj3(ps.traj)))
+}
@@ -0,0 +1,62 @@
+package tda
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPersistence3D(t *testing.T) {
+
+	// A volume with one row, five columns and three slices.
+	img := []int{
+		0, 0, 0, 0, 0,
+		0, 3, 1, 2, 0,
+		0, 0, 0, 0, 0,
+	}
+
+	expected := []Trajectory3D{
+		{
+			{Label: 1, Size: 15, Max: 3, Step: 0, Threshold: 0, Bbox: Box3{Point3{0, 0, 0}, Point3{5, 1, 3}}},
+			{Label: 1, Size: 3, Max: 3, Step: 1, Threshold: 1, Bbox: Box3{Point3{1, 0, 1}, Point3{4, 1, 2}}},
+			{Label: 1, Size: 1, Max: 3, Step: 2, Threshold: 2, Bbox: Box3{Point3{1, 0, 1}, Point3{2, 1, 2}}},
+			{Label: 1, Size: 1, Max: 3, Step: 3, Threshold: 3, Bbox: Box3{Point3{1, 0, 1}, Point3{2, 1, 2}}},
+		},
+		{
+			{Label: 2, Size: 1, Max: 2, Step: 2, Threshold: 2, Bbox: Box3{Point3{3, 0, 1}, Point3{4, 1, 2}}},
+		},
+	}
+
+	for _, conn := range []int{6, 18, 26} {
+
+		ps := NewPersistence3D(img, 1, 5, 4, conn)
+		ps.Sort()
+		traj := ps.Trajectories()
+
+		if len(traj) != len(expected) {
+			fmt.Printf("Found %d trajectories, expected %d with conn=%d.\n",
+				len(traj), len(expected), conn)
+			fmt.Printf("Got:\n%+v\n", traj)
+			t.Fail()
+			continue
+		}
+
+		for i := range traj {
+			match := len(traj[i]) == len(expected[i])
+			for j := 0; match && j < len(traj[i]); j++ {
+				match = traj[i][j] == expected[i][j]
+			}
+			if !match {
+				fmt.Printf("Failed trajectory %d with conn=%d\nGot:\n", i, conn)
+				fmt.Printf("%+v\n", traj[i])
+				fmt.Printf("Expected:\n%+v\n", expected[i])
+				t.Fail()
+			}
+		}
+
+		birth, death := ps.BirthDeath()
+		if birth[0] != 0 || death[0] != 3 || birth[1] != 2 || death[1] != 2 {
+			fmt.Printf("Unexpected birth/death times %v %v\n", birth, death)
+			t.Fail()
+		}
+	}
+}
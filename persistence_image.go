@@ -0,0 +1,189 @@
+package tda
+
+import (
+	"image"
+	"math"
+)
+
+// PersistenceImageOptions contains optional settings for
+// NewPersistenceImage.
+type PersistenceImageOptions struct {
+
+	// If true, the points of the diagrams are placed on the grid
+	// using their birth and death times.  Otherwise (the default),
+	// the points are placed using their birth times and
+	// persistence, which is the absolute difference between the
+	// birth and death times.
+	BirthDeath bool
+
+	// The weight given to a point with the given birth time and
+	// persistence.  If nil, the weight is the persistence, so
+	// that points near the diagonal have little influence.
+	Weight func(birth, persistence float64) float64
+
+	// The standard deviation of the Gaussian kernel placed at each
+	// point.  If zero, the greater of the width and the height of
+	// a grid cell is used.
+	Sigma float64
+}
+
+// PersistenceImage converts persistence diagrams to fixed-length
+// vectors (Adams et al., 2017, JMLR 18:8).  Each point of a diagram is
+// replaced by a weighted Gaussian density centered at the point, and
+// the sum of these densities is integrated over the cells of a
+// rectangular grid.  The same PersistenceImage value can be used to
+// vectorize many diagrams, giving vectors that can be compared
+// element by element.
+type PersistenceImage struct {
+
+	// The number of grid cells in the vertical (persistence or
+	// death) and horizontal (birth) directions
+	rows, cols int
+
+	// The range of the grid in the horizontal and vertical
+	// directions
+	xmin, xmax, ymin, ymax float64
+
+	opts PersistenceImageOptions
+}
+
+// NewPersistenceImage returns a PersistenceImage that rasterizes
+// persistence diagrams onto a grid with the given number of rows and
+// columns.  The grid covers birth times from xmin to xmax, and
+// persistence (or death times, see PersistenceImageOptions) from ymin
+// to ymax.
+func NewPersistenceImage(rows, cols int, xmin, xmax, ymin, ymax float64, opts PersistenceImageOptions) *PersistenceImage {
+
+	if rows <= 0 || cols <= 0 {
+		panic("rows and cols must be positive")
+	}
+	if xmax <= xmin || ymax <= ymin {
+		panic("empty grid")
+	}
+
+	if opts.Sigma == 0 {
+		opts.Sigma = math.Max((xmax-xmin)/float64(cols), (ymax-ymin)/float64(rows))
+	}
+	if opts.Weight == nil {
+		opts.Weight = func(_, persistence float64) float64 {
+			return persistence
+		}
+	}
+
+	return &PersistenceImage{
+		rows: rows,
+		cols: cols,
+		xmin: xmin,
+		xmax: xmax,
+		ymin: ymin,
+		ymax: ymax,
+		opts: opts,
+	}
+}
+
+// Dims returns the number of rows and columns of the grid.
+func (pi *PersistenceImage) Dims() (int, int) {
+	return pi.rows, pi.cols
+}
+
+// cellMass places into buf the Gaussian probability of each of the n
+// intervals that partition [lo, hi] into equal parts, for a Gaussian
+// distribution with the given mean and standard deviation, and
+// returns it.
+func cellMass(lo, hi float64, n int, mean, sigma float64, buf []float64) []float64 {
+
+	if cap(buf) < n {
+		buf = make([]float64, n)
+	}
+	buf = buf[0:n]
+
+	w := (hi - lo) / float64(n)
+	cdf := func(x float64) float64 {
+		return 0.5 * math.Erfc(-(x-mean)/(sigma*math.Sqrt2))
+	}
+	c0 := cdf(lo)
+	for i := range buf {
+		c1 := cdf(lo + float64(i+1)*w)
+		buf[i] = c1 - c0
+		c0 = c1
+	}
+
+	return buf
+}
+
+// Vector returns the persistence image of the diagram with the given
+// birth and death times, as a slice of length rows*cols.  The value
+// in position i*cols + j is the integral over the grid cell in row i
+// and column j, where the rows are in order of increasing persistence
+// (or death time), and the columns are in order of increasing birth
+// time.  The integrals are exact.  Points with infinite death times
+// are ignored.
+func (pi *PersistenceImage) Vector(birth, death []float64) []float64 {
+
+	if len(birth) != len(death) {
+		panic("birth and death slices must have the same length")
+	}
+
+	v := make([]float64, pi.rows*pi.cols)
+	var mx, my []float64
+	for k := range birth {
+
+		b, d := birth[k], death[k]
+		if math.IsInf(d, 0) {
+			continue
+		}
+
+		p := math.Abs(d - b)
+		w := pi.opts.Weight(b, p)
+		if w == 0 {
+			continue
+		}
+		y := p
+		if pi.opts.BirthDeath {
+			y = d
+		}
+
+		// The Gaussian kernel is a product of univariate
+		// kernels, so its integral over a cell is a product.
+		mx = cellMass(pi.xmin, pi.xmax, pi.cols, b, pi.opts.Sigma, mx)
+		my = cellMass(pi.ymin, pi.ymax, pi.rows, y, pi.opts.Sigma, my)
+		for i, u := range my {
+			row := v[i*pi.cols : (i+1)*pi.cols]
+			for j, z := range mx {
+				row[j] += w * u * z
+			}
+		}
+	}
+
+	return v
+}
+
+// Image renders a vector returned by Vector as a grayscale image, for
+// display.  The values are scaled so that the greatest value is white
+// and zero is black (negative values are also black).  The rows are
+// flipped, so that persistence (or death time) increases from the
+// bottom to the top of the image.
+func (pi *PersistenceImage) Image(v []float64) *image.Gray {
+
+	if len(v) != pi.rows*pi.cols {
+		panic("vector length does not match the grid")
+	}
+
+	var mx float64
+	for _, x := range v {
+		mx = math.Max(mx, x)
+	}
+
+	img := image.NewGray(image.Rect(0, 0, pi.cols, pi.rows))
+	if mx == 0 {
+		return img
+	}
+	for i := 0; i < pi.rows; i++ {
+		for j := 0; j < pi.cols; j++ {
+			x := math.Max(v[i*pi.cols+j], 0)
+			img.Pix[(pi.rows-1-i)*img.Stride+j] = uint8(math.Round(255 * x / mx))
+		}
+	}
+
+	return img
+}
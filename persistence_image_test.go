@@ -0,0 +1,63 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestPersistenceImage(t *testing.T) {
+
+	birth := []float64{1, 2, 0}
+	death := []float64{3, 7, math.Inf(1)}
+
+	// A grid that covers the points by many standard deviations
+	// holds their total weight.
+	pi := NewPersistenceImage(40, 50, -10, 20, -10, 20, PersistenceImageOptions{Sigma: 0.5})
+	v := pi.Vector(birth, death)
+	if len(v) != 40*50 {
+		fmt.Printf("Vector has length %d, expected %d\n", len(v), 40*50)
+		t.Fail()
+	}
+	if s := floats.Sum(v); math.Abs(s-7) > 1e-10 {
+		fmt.Printf("Total weight is %v, expected 7\n", s)
+		t.Fail()
+	}
+
+	// A single point in the center of a symmetric grid
+	pi = NewPersistenceImage(3, 3, 0, 3, 0, 3, PersistenceImageOptions{
+		Weight: func(_, _ float64) float64 { return 1 },
+	})
+	v = pi.Vector([]float64{1.5}, []float64{3})
+	if floats.MaxIdx(v) != 4 {
+		fmt.Printf("The center cell is not the largest\n")
+		t.Fail()
+	}
+	for _, pr := range [][2]int{{0, 8}, {1, 7}, {2, 6}, {3, 5}, {0, 2}} {
+		if math.Abs(v[pr[0]]-v[pr[1]]) > 1e-12 {
+			fmt.Printf("Image is not symmetric\n")
+			t.Fail()
+		}
+	}
+
+	// The death time places the point in the top row.
+	pi = NewPersistenceImage(3, 3, 0, 3, 0, 3, PersistenceImageOptions{BirthDeath: true, Sigma: 0.1})
+	v = pi.Vector([]float64{0.5}, []float64{2.5})
+	if floats.MaxIdx(v) != 6 || math.Abs(floats.Sum(v)-2) > 1e-5 {
+		fmt.Printf("Unexpected vector %v\n", v)
+		t.Fail()
+	}
+
+	// The top row of the vector is the top row of the image.
+	img := pi.Image(v)
+	if b := img.Bounds(); b.Dx() != 3 || b.Dy() != 3 {
+		fmt.Printf("Image has bounds %v\n", b)
+		t.Fail()
+	}
+	if img.GrayAt(0, 0).Y != 255 || img.GrayAt(2, 2).Y != 0 {
+		fmt.Printf("Unexpected image %v\n", img.Pix)
+		t.Fail()
+	}
+}
@@ -3,7 +3,10 @@ package tda
 import (
 	"fmt"
 	"image"
+	"reflect"
 	"testing"
+
+	"gonum.org/v1/gonum/floats"
 )
 
 var (
@@ -26,7 +29,7 @@ var (
 			4,
 			[][]Pstate{
 				{
-					{Label: 1, Size: 36, Max: 3, Step: 0, Threshold: 0, Bbox: image.Rect(1, 1, 7, 7)},
+					{Label: 1, Size: 64, Max: 3, Step: 0, Threshold: 0, Bbox: image.Rect(0, 0, 8, 8)},
 					{Label: 1, Size: 36, Max: 3, Step: 1, Threshold: 1, Bbox: image.Rect(1, 1, 7, 7)},
 					{Label: 2, Size: 18, Max: 3, Step: 2, Threshold: 2, Bbox: image.Rect(4, 1, 7, 7)},
 					{Label: 2, Size: 9, Max: 3, Step: 3, Threshold: 3, Bbox: image.Rect(4, 1, 7, 4)},
@@ -54,7 +57,7 @@ var (
 			5,
 			[][]Pstate{
 				{
-					{Label: 1, Size: 36, Max: 9, Step: 0, Threshold: 0, Bbox: image.Rect(1, 1, 7, 7)},
+					{Label: 1, Size: 64, Max: 9, Step: 0, Threshold: 0, Bbox: image.Rect(0, 0, 8, 8)},
 					{Label: 1, Size: 31, Max: 9, Step: 1, Threshold: 2, Bbox: image.Rect(1, 1, 7, 7)},
 					{Label: 1, Size: 29, Max: 9, Step: 2, Threshold: 4, Bbox: image.Rect(1, 1, 7, 7)},
 					{Label: 1, Size: 8, Max: 9, Step: 3, Threshold: 6, Bbox: image.Rect(1, 1, 4, 7)},
@@ -83,7 +86,7 @@ var (
 			5,
 			[][]Pstate{
 				{
-					{Label: 1, Size: 36, Max: 7, Step: 0, Threshold: 0, Bbox: image.Rect(1, 1, 7, 7)},
+					{Label: 1, Size: 64, Max: 7, Step: 0, Threshold: 0, Bbox: image.Rect(0, 0, 8, 8)},
 					{Label: 1, Size: 36, Max: 7, Step: 1, Threshold: 1, Bbox: image.Rect(1, 1, 7, 7)},
 					{Label: 1, Size: 23, Max: 7, Step: 2, Threshold: 3, Bbox: image.Rect(1, 1, 7, 7)},
 					{Label: 2, Size: 5, Max: 7, Step: 3, Threshold: 5, Bbox: image.Rect(5, 1, 7, 4)},
@@ -141,3 +144,166 @@ func compareTraj(x, y []Pstate) bool {
 	}
 	return true
 }
+
+func TestStepThresholds(t *testing.T) {
+
+	for _, tst := range []struct {
+		mn, mx, steps int
+		thresh        []int
+	}{
+		{0, 10, 1, nil},
+		{0, 10, 2, []int{10}},
+		{0, 10, 3, []int{5, 10}},
+		{2, 9, 4, []int{4, 6, 9}},
+	} {
+		if th := stepThresholds(tst.mn, tst.mx, tst.steps); !reflect.DeepEqual(th, tst.thresh) {
+			fmt.Printf("Thresholds %v, expected %v\n", th, tst.thresh)
+			t.Fail()
+		}
+	}
+}
+
+func TestPersistenceConn(t *testing.T) {
+
+	// Two bright regions that touch only at a corner.
+	img := []int{
+		0, 0, 0, 0, 0, 0,
+		0, 5, 5, 0, 0, 0,
+		0, 5, 5, 0, 0, 0,
+		0, 0, 0, 4, 4, 0,
+		0, 0, 0, 4, 4, 0,
+		0, 0, 0, 0, 0, 0,
+	}
+
+	for _, tr := range []struct {
+		conn  int
+		birth []float64
+		death []float64
+	}{
+		{
+			conn:  4,
+			birth: []float64{0, 1},
+			death: []float64{5, 4},
+		},
+		{
+			conn:  8,
+			birth: []float64{0},
+			death: []float64{5},
+		},
+	} {
+		ps := NewPersistenceOpts(img, 6, 6, PersistenceOptions{Conn: tr.conn})
+		ps.Sort()
+		birth, death := ps.BirthDeath()
+
+		if !floats.Equal(birth, tr.birth) || !floats.Equal(death, tr.death) {
+			fmt.Printf("Birth/death times do not match with conn=%d\n", tr.conn)
+			fmt.Printf("Got %v, %v\nExpected %v, %v\n", birth, death, tr.birth, tr.death)
+			t.Fail()
+		}
+	}
+}
+
+func TestPersistenceWorkers(t *testing.T) {
+
+	for jt, test := range pertests {
+
+		var img []int
+		for _, row := range test.img {
+			img = append(img, row...)
+		}
+
+		ps := NewPersistenceOpts(img, 8, test.isteps, PersistenceOptions{Workers: 3})
+		ps.Sort()
+		traj := ps.Trajectories()
+
+		if len(traj) != len(test.traj) {
+			fmt.Printf("Found %d trajectories, expected %d in test %d.\n",
+				len(traj), len(test.traj), jt)
+			t.Fail()
+			continue
+		}
+
+		for i := range traj {
+			if !compareTraj(traj[i], test.traj[i]) {
+				fmt.Printf("Failed test %d, trajectory %d with 3 workers\n", jt, i)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestPersistenceBranches(t *testing.T) {
+
+	expected := []struct {
+		branches []Branch
+		events   []Event
+	}{
+		{
+			branches: []Branch{{Parent: -1, Step: 0}, {Parent: 0, Step: 2}, {Parent: 0, Step: 3}},
+			events: []Event{
+				{Step: 2, Threshold: 2, Parent: 0, Children: []int{1}},
+				{Step: 3, Threshold: 3, Parent: 0, Children: []int{2}},
+			},
+		},
+		{
+			branches: []Branch{{Parent: -1, Step: 0}, {Parent: 0, Step: 3}, {Parent: 0, Step: 3}},
+			events: []Event{
+				{Step: 3, Threshold: 6, Parent: 0, Children: []int{1, 2}},
+			},
+		},
+		{
+			branches: []Branch{{Parent: -1, Step: 0}, {Parent: 0, Step: 3}},
+			events: []Event{
+				{Step: 3, Threshold: 5, Parent: 0, Children: []int{1}},
+			},
+		},
+	}
+
+	for jt, test := range pertests {
+
+		var img []int
+		for _, row := range test.img {
+			img = append(img, row...)
+		}
+
+		ps := NewPersistence(img, 8, test.isteps)
+		ps.Sort()
+
+		if !compareBranches(ps.Branches(), expected[jt].branches) {
+			fmt.Printf("Branches do not match in test %d\n", jt)
+			fmt.Printf("Got %+v\nExpected %+v\n", ps.Branches(), expected[jt].branches)
+			t.Fail()
+		}
+
+		if !compareEvents(ps.Events(), expected[jt].events) {
+			fmt.Printf("Events do not match in test %d\n", jt)
+			fmt.Printf("Got %+v\nExpected %+v\n", ps.Events(), expected[jt].events)
+			t.Fail()
+		}
+	}
+}
+
+func compareBranches(x, y []Branch) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func compareEvents(x, y []Event) bool {
+	if len(x) != len(y) {
+		return false
+	}
+	for i := range x {
+		if x[i].Step != y[i].Step || x[i].Threshold != y[i].Threshold ||
+			x[i].Parent != y[i].Parent || !compareLabels(x[i].Children, y[i].Children) {
+			return false
+		}
+	}
+	return true
+}
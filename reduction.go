@@ -0,0 +1,598 @@
+package tda
+
+import (
+	"math"
+	"sort"
+)
+
+// BoundaryMatrix is the sparse boundary matrix of a filtered cell
+// complex.  Column j holds the boundary of the cell at position j of
+// the filtration, as the positions of its faces (which must precede
+// it) and the corresponding coefficients.  Only the nonzero entries
+// are stored.
+type BoundaryMatrix struct {
+
+	// The dimension of each cell
+	dims []int
+
+	// The filtration value of each cell
+	values []float64
+
+	// The row positions of the nonzero entries in each column, in
+	// increasing order
+	rows [][]int
+
+	// The coefficients of the nonzero entries in each column
+	coefs [][]int
+
+	// The vertices or pixels that make up each cell, if known
+	support [][]int
+}
+
+// NewBoundaryMatrix returns an empty boundary matrix, to which cells
+// can be added in filtration order with AddColumn.
+func NewBoundaryMatrix() *BoundaryMatrix {
+	return &BoundaryMatrix{}
+}
+
+// AddColumn adds a cell of the given dimension and filtration value
+// to the end of the filtration.  The boundary of the cell is given by
+// the positions of its faces in the filtration and the corresponding
+// coefficients (usually 1 or -1).  If coefs is nil, all coefficients
+// are 1, which is sufficient for calculations over Z/2.
+func (bm *BoundaryMatrix) AddColumn(dim int, value float64, rows, coefs []int) {
+
+	j := len(bm.rows)
+	r := make([]int, len(rows))
+	c := make([]int, len(rows))
+	copy(r, rows)
+	if coefs == nil {
+		for i := range c {
+			c[i] = 1
+		}
+	} else {
+		if len(coefs) != len(rows) {
+			panic("rows and coefs must have the same length")
+		}
+		copy(c, coefs)
+	}
+
+	ord := make([]int, len(r))
+	for i := range ord {
+		ord[i] = i
+		if r[i] >= j {
+			panic("the faces of a cell must precede it in the filtration")
+		}
+	}
+	sort.Slice(ord, func(a, b int) bool { return r[ord[a]] < r[ord[b]] })
+	rs := make([]int, len(r))
+	cs := make([]int, len(r))
+	for i, k := range ord {
+		rs[i] = r[k]
+		cs[i] = c[k]
+	}
+
+	bm.dims = append(bm.dims, dim)
+	bm.values = append(bm.values, value)
+	bm.rows = append(bm.rows, rs)
+	bm.coefs = append(bm.coefs, cs)
+	bm.support = append(bm.support, nil)
+}
+
+// NumColumns returns the number of cells in the filtration.
+func (bm *BoundaryMatrix) NumColumns() int {
+	return len(bm.rows)
+}
+
+// Dim returns the dimension of the cell at position j.
+func (bm *BoundaryMatrix) Dim(j int) int {
+	return bm.dims[j]
+}
+
+// Value returns the filtration value of the cell at position j.
+func (bm *BoundaryMatrix) Value(j int) float64 {
+	return bm.values[j]
+}
+
+// Column returns the row positions and coefficients of the nonzero
+// entries in column j.
+func (bm *BoundaryMatrix) Column(j int) ([]int, []int) {
+	return bm.rows[j], bm.coefs[j]
+}
+
+// Support returns the points that make up the cell at position j,
+// which are the vertices of a simplex for NewSimplicialBoundaryMatrix,
+// and the pixel (as a linear index) that determines the filtration
+// value of a cell for NewImageBoundaryMatrix.  Support returns nil for
+// cells added with AddColumn.
+func (bm *BoundaryMatrix) Support(j int) []int {
+	return bm.support[j]
+}
+
+// NewSimplicialBoundaryMatrix returns the boundary matrix of a
+// filtered simplicial complex.  The simplices must be given in
+// filtration order, with faces preceding their cofaces, and each
+// simplex must be an increasing sequence of vertices.  The face
+// obtained by removing vertex i of a simplex has coefficient (-1)^i.
+func NewSimplicialBoundaryMatrix(simplices [][]int, values []float64) *BoundaryMatrix {
+
+	if len(simplices) != len(values) {
+		panic("simplices and values must have the same length")
+	}
+
+	// The position of each simplex in the filtration, indexed by
+	// dimension and key.
+	var index []map[int]int
+	for k, s := range simplices {
+		for len(index) < len(s) {
+			index = append(index, make(map[int]int))
+		}
+		index[len(s)-1][simplexKey(s)] = k
+	}
+
+	bm := NewBoundaryMatrix()
+	var face, rows, coefs []int
+	for k, s := range simplices {
+		rows = rows[0:0]
+		coefs = coefs[0:0]
+		if len(s) > 1 {
+			for i := range s {
+				face = append(face[0:0], s[0:i]...)
+				face = append(face, s[i+1:]...)
+				p, ok := index[len(face)-1][simplexKey(face)]
+				if !ok {
+					panic("a face of a simplex is missing from the filtration")
+				}
+				rows = append(rows, p)
+				coefs = append(coefs, 1-2*(i%2))
+			}
+		}
+		bm.AddColumn(len(s)-1, values[k], rows, coefs)
+		bm.support[k] = append([]int(nil), s...)
+	}
+
+	return bm
+}
+
+// NewImageBoundaryMatrix returns the boundary matrix of the cubical
+// complex filtered by the superlevel sets {img >= t} of an image,
+// which must be rectangular with the given number of rows.  Each pixel
+// is a square cell, and its edges and vertices enter the filtration
+// with the brightest pixel that contains them, so that pixels touching
+// at a corner are connected, as in NewExactPersistence and
+// NewCubicalPersistence.  The filtration values are the negated
+// intensities, so that they increase along the filtration and birth
+// values are less than death values.
+func NewImageBoundaryMatrix(img []int, rows int) *BoundaryMatrix {
+
+	cols := len(img) / rows
+	if rows*cols != len(img) {
+		panic("rows is not compatible with img")
+	}
+
+	// The cells are indexed by their positions (a, b) on a grid
+	// with 2*rows+1 rows and 2*cols+1 columns, on which pixel
+	// (i, j) is at position (2i+1, 2j+1).
+	gr, gc := 2*rows+1, 2*cols+1
+	n := gr * gc
+	dim := make([]int, n)
+	val := make([]int, n)
+	pix := make([]int, n)
+	for a := 0; a < gr; a++ {
+		for b := 0; b < gc; b++ {
+			c := a*gc + b
+			dim[c] = a%2 + b%2
+			val[c] = math.MinInt64
+			for i := (a - 1) / 2; i <= a/2; i++ {
+				for j := (b - 1) / 2; j <= b/2; j++ {
+					if i >= 0 && i < rows && j >= 0 && j < cols && img[i*cols+j] > val[c] {
+						val[c] = img[i*cols+j]
+						pix[c] = i*cols + j
+					}
+				}
+			}
+		}
+	}
+
+	ord := make([]int, n)
+	for i := range ord {
+		ord[i] = i
+	}
+	sort.SliceStable(ord, func(i, j int) bool {
+		if val[ord[i]] != val[ord[j]] {
+			return val[ord[i]] > val[ord[j]]
+		}
+		return dim[ord[i]] < dim[ord[j]]
+	})
+	pos := make([]int, n)
+	for k, c := range ord {
+		pos[c] = k
+	}
+
+	// The faces of a cell are its neighbors along each odd
+	// coordinate, with alternating signs.
+	bm := NewBoundaryMatrix()
+	var fr, fc []int
+	for _, c := range ord {
+		a, b := c/gc, c%gc
+		fr = fr[0:0]
+		fc = fc[0:0]
+		sign := 1
+		if a%2 == 1 {
+			fr = append(fr, pos[c-gc], pos[c+gc])
+			fc = append(fc, -1, 1)
+			sign = -1
+		}
+		if b%2 == 1 {
+			fr = append(fr, pos[c-1], pos[c+1])
+			fc = append(fc, -sign, sign)
+		}
+		bm.AddColumn(dim[c], -float64(val[c]), fr, fc)
+		bm.support[len(bm.support)-1] = []int{pix[c]}
+	}
+
+	return bm
+}
+
+// ReductionAlgorithm specifies the algorithm used to reduce a
+// boundary matrix.
+type ReductionAlgorithm int
+
+const (
+	// TwistReduction reduces the columns in order of decreasing
+	// dimension, and skips the columns that are known to reduce to
+	// zero because they have been paired as the lowest entry of
+	// another column (clearing).
+	TwistReduction ReductionAlgorithm = iota
+
+	// StandardReduction reduces the columns from left to right.
+	StandardReduction
+
+	// CompressedReduction reduces the columns in order of
+	// increasing dimension, and removes from each column the
+	// entries for cells that are known to destroy a class, which
+	// do not affect the persistence pairs (compression).
+	CompressedReduction
+
+	// CohomologyReduction reduces the coboundary matrix with
+	// clearing, which is often much faster for Rips filtrations.
+	// The persistence pairs are the same as for homology.
+	CohomologyReduction
+)
+
+// ReduceOptions contains optional arguments for reducing a boundary
+// matrix.
+type ReduceOptions struct {
+
+	// The reduction algorithm, the default is TwistReduction
+	Algorithm ReductionAlgorithm
+
+	// The coefficients are taken from the integers modulo this
+	// number, which must be prime.  If zero, Z/2 is used.
+	Modulus int
+}
+
+// maxDim returns the greatest dimension of a cell, or -1 if there are
+// no cells.
+func (bm *BoundaryMatrix) maxDim() int {
+	maxd := -1
+	for _, d := range bm.dims {
+		if d > maxd {
+			maxd = d
+		}
+	}
+	return maxd
+}
+
+// columns returns the columns of the boundary matrix with coefficients
+// in Z/p.
+func (bm *BoundaryMatrix) columns(p int) []sparseColumn {
+	cols := make([]sparseColumn, len(bm.rows))
+	for j := range cols {
+		cols[j].rows = bm.rows[j]
+		cols[j].vals = make([]int, len(bm.coefs[j]))
+		for i, c := range bm.coefs[j] {
+			cols[j].vals[i] = (c%p + p) % p
+		}
+	}
+	return cols
+}
+
+// sparseColumn is a column of a sparse matrix over Z/p.  The rows are
+// in increasing order, and the values are in 1, ..., p-1.
+type sparseColumn struct {
+	rows []int
+	vals []int
+}
+
+// isPrime returns true if p is a prime number, using trial division.
+func isPrime(p int) bool {
+	if p < 2 {
+		return false
+	}
+	for q := 2; q*q <= p; q++ {
+		if p%q == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// modInverse returns the inverse of x modulo the prime p.
+func modInverse(x, p int) int {
+	r, b, e := 1, x%p, p-2
+	for e > 0 {
+		if e%2 == 1 {
+			r = r * b % p
+		}
+		b = b * b % p
+		e /= 2
+	}
+	return r
+}
+
+// subColumn places a - f*b over Z/p into buf, and returns it.
+func subColumn(a, b sparseColumn, f, p int, buf sparseColumn) sparseColumn {
+
+	buf.rows = buf.rows[0:0]
+	buf.vals = buf.vals[0:0]
+	i, j := 0, 0
+	for i < len(a.rows) || j < len(b.rows) {
+		switch {
+		case j == len(b.rows) || (i < len(a.rows) && a.rows[i] < b.rows[j]):
+			buf.rows = append(buf.rows, a.rows[i])
+			buf.vals = append(buf.vals, a.vals[i])
+			i++
+		case i == len(a.rows) || b.rows[j] < a.rows[i]:
+			buf.rows = append(buf.rows, b.rows[j])
+			buf.vals = append(buf.vals, (p-f*b.vals[j]%p)%p)
+			j++
+		default:
+			if v := ((a.vals[i]-f*b.vals[j])%p + p) % p; v != 0 {
+				buf.rows = append(buf.rows, a.rows[i])
+				buf.vals = append(buf.vals, v)
+			}
+			i++
+			j++
+		}
+	}
+
+	return buf
+}
+
+// reduceColumns reduces the columns of a sparse matrix over Z/p,
+// visiting the columns in the given order.  A column can only be
+// reduced using columns visited before it, which must be to its left.
+// If clearing is true, the column whose position is the lowest entry
+// of a reduced column is skipped.  If compress is true, entries in
+// rows whose columns have already been reduced to nonzero columns are
+// removed.  The lowest nonzero position of each reduced column is
+// returned, or -1 if the reduced column is zero.  The columns are
+// replaced by their reduced forms.
+func reduceColumns(cols []sparseColumn, order []int, p int, clearing, compress bool) []int {
+
+	n := len(cols)
+	low := make([]int, n)
+	pivot := make([]int, n)
+	skip := make([]bool, n)
+	for j := range low {
+		low[j] = -1
+		pivot[j] = -1
+	}
+
+	var buf sparseColumn
+	for _, j := range order {
+
+		if skip[j] {
+			cols[j] = sparseColumn{}
+			continue
+		}
+
+		col := cols[j]
+		if compress {
+			var c sparseColumn
+			for k, i := range col.rows {
+				if low[i] == -1 {
+					c.rows = append(c.rows, i)
+					c.vals = append(c.vals, col.vals[k])
+				}
+			}
+			col = c
+		} else {
+			col = sparseColumn{
+				rows: append([]int(nil), col.rows...),
+				vals: append([]int(nil), col.vals...),
+			}
+		}
+
+		for len(col.rows) > 0 {
+			m := len(col.rows) - 1
+			k := pivot[col.rows[m]]
+			if k == -1 {
+				break
+			}
+			km := len(cols[k].rows) - 1
+			f := col.vals[m] * modInverse(cols[k].vals[km], p) % p
+			buf = subColumn(col, cols[k], f, p, buf)
+			col, buf = buf, col
+		}
+
+		cols[j] = col
+		if len(col.rows) > 0 {
+			i := col.rows[len(col.rows)-1]
+			low[j] = i
+			pivot[i] = j
+			if clearing {
+				skip[i] = true
+			}
+		}
+	}
+
+	return low
+}
+
+// Reduce reduces the boundary matrix and returns the persistence
+// pairs, indexed by dimension.  Classes that never die have an
+// infinite death value and a Destroyer of -1.  Pairs in which the
+// birth and death values are equal are omitted.  The pairs in each
+// dimension are ordered by the position of the destroying cell,
+// followed by the classes that never die ordered by the position of
+// the creating cell.
+func (bm *BoundaryMatrix) Reduce(opts ReduceOptions) [][]PersistencePair {
+
+	p := opts.Modulus
+	if p == 0 {
+		p = 2
+	}
+	if !isPrime(p) {
+		panic("Modulus must be a prime number")
+	}
+
+	n := len(bm.rows)
+	maxd := bm.maxDim()
+	cols := bm.columns(p)
+
+	// The positions of the cells of each dimension
+	bydim := make([][]int, maxd+1)
+	for j, d := range bm.dims {
+		bydim[d] = append(bydim[d], j)
+	}
+
+	// low[j] is the creator paired with destroyer j, or -1
+	var low []int
+	var order []int
+	switch opts.Algorithm {
+	case StandardReduction:
+		for j := 0; j < n; j++ {
+			order = append(order, j)
+		}
+		low = reduceColumns(cols, order, p, false, false)
+	case TwistReduction:
+		for d := maxd; d >= 0; d-- {
+			order = append(order, bydim[d]...)
+		}
+		low = reduceColumns(cols, order, p, true, false)
+	case CompressedReduction:
+		for d := 0; d <= maxd; d++ {
+			order = append(order, bydim[d]...)
+		}
+		low = reduceColumns(cols, order, p, false, true)
+	case CohomologyReduction:
+		low = bm.reduceCohomology(cols, bydim, p)
+	default:
+		panic("unknown reduction algorithm")
+	}
+
+	return bm.pairs(low, maxd)
+}
+
+// pairs returns the persistence pairs defined by the lowest entries of
+// the reduced columns, as in Reduce.
+func (bm *BoundaryMatrix) pairs(low []int, maxd int) [][]PersistencePair {
+
+	pairs := make([][]PersistencePair, maxd+1)
+	paired := make([]bool, len(low))
+	for j, i := range low {
+		if i == -1 {
+			continue
+		}
+		paired[i] = true
+		paired[j] = true
+		if bm.values[i] < bm.values[j] {
+			d := bm.dims[i]
+			pairs[d] = append(pairs[d], PersistencePair{
+				Dim:       d,
+				Birth:     bm.values[i],
+				Death:     bm.values[j],
+				Creator:   i,
+				Destroyer: j,
+			})
+		}
+	}
+
+	for k := range paired {
+		if !paired[k] {
+			d := bm.dims[k]
+			pairs[d] = append(pairs[d], PersistencePair{
+				Dim:       d,
+				Birth:     bm.values[k],
+				Death:     math.Inf(1),
+				Creator:   k,
+				Destroyer: -1,
+			})
+		}
+	}
+
+	return pairs
+}
+
+// reduceCohomology reduces the coboundary matrix, which is the
+// anti-transpose of the boundary matrix, and returns the pairing in
+// the same form as reduceColumns does for the boundary matrix.
+func (bm *BoundaryMatrix) reduceCohomology(cols []sparseColumn, bydim [][]int, p int) []int {
+
+	// In the coboundary matrix, the cell at position i is at
+	// position n-1-i, and column n-1-i holds the cofaces of the
+	// cell.
+	n := len(cols)
+	cob := make([]sparseColumn, n)
+	for j := n - 1; j >= 0; j-- {
+		for k, i := range cols[j].rows {
+			c := &cob[n-1-i]
+			c.rows = append(c.rows, n-1-j)
+			c.vals = append(c.vals, cols[j].vals[k])
+		}
+	}
+
+	// Reduce the cells in order of increasing dimension, so that
+	// clearing applies, and from left to right in the coboundary
+	// matrix within each dimension.
+	var order []int
+	for _, cells := range bydim {
+		for k := len(cells) - 1; k >= 0; k-- {
+			order = append(order, n-1-cells[k])
+		}
+	}
+	clow := reduceColumns(cob, order, p, true, false)
+
+	// A coboundary column for the cell at i with lowest entry at
+	// the cell j pairs i as the creator with j as the destroyer.
+	low := make([]int, n)
+	for j := range low {
+		low[j] = -1
+	}
+	for cj, ci := range clow {
+		if ci != -1 {
+			low[n-1-ci] = n - 1 - cj
+		}
+	}
+
+	return low
+}
+
+// filtrationPairs reduces the boundary matrix of a simplicial
+// filtration to obtain the persistence pairs in dimensions 0 through
+// maxDim, as a single slice.  The simplices must be given in
+// filtration order, with faces preceding their cofaces, and each
+// simplex must be an increasing sequence of vertices.  Classes that
+// never die are given the death value end.  Pairs in which the birth
+// and death values are equal are omitted.
+func filtrationPairs(simplices [][]int, values []float64, maxDim int, end float64) []PersistencePair {
+
+	bm := NewSimplicialBoundaryMatrix(simplices, values)
+
+	var pairs []PersistencePair
+	for d, pd := range bm.Reduce(ReduceOptions{}) {
+		if d > maxDim {
+			break
+		}
+		for _, pr := range pd {
+			if pr.Destroyer == -1 {
+				pr.Death = end
+			}
+			pairs = append(pairs, pr)
+		}
+	}
+
+	return pairs
+}
@@ -0,0 +1,203 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+var allReductions = []ReductionAlgorithm{
+	TwistReduction,
+	StandardReduction,
+	CompressedReduction,
+	CohomologyReduction,
+}
+
+// All algorithms and coefficient fields give the same pairs for Rips
+// filtrations, which have no torsion in low dimensions.
+func TestReduceAlgorithms(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(91))
+
+	for jt := 0; jt < 5; jt++ {
+
+		n := 10 + rng.Intn(10)
+		x := make([]float64, 2*n)
+		for i := range x {
+			x[i] = rng.Float64()
+		}
+		bm := NewRips(x, 2, 0.6, 2).BoundaryMatrix()
+
+		base := bm.Reduce(ReduceOptions{})
+		for _, alg := range allReductions {
+			for _, p := range []int{2, 3, 7} {
+				pairs := bm.Reduce(ReduceOptions{Algorithm: alg, Modulus: p})
+				if !reflect.DeepEqual(pairs, base) {
+					fmt.Printf("Test %d: algorithm %d with modulus %d differs\n", jt, alg, p)
+					t.Fail()
+				}
+			}
+		}
+	}
+}
+
+// The real projective plane has homology Z/2 in dimensions one and
+// two over Z/2, but not over Z/3.
+func TestReduceTorsion(t *testing.T) {
+
+	tri := [][]int{
+		{0, 1, 2}, {0, 2, 3}, {0, 3, 4}, {0, 4, 5}, {0, 1, 5},
+		{1, 2, 4}, {1, 3, 4}, {1, 3, 5}, {2, 3, 5}, {2, 4, 5},
+	}
+
+	st := NewSimplexTree()
+	for _, s := range tri {
+		st.Insert(s, 0)
+	}
+	bm := st.BoundaryMatrix()
+
+	for _, alg := range allReductions {
+		for _, p := range []int{2, 3} {
+			pairs := bm.Reduce(ReduceOptions{Algorithm: alg, Modulus: p})
+			var betti []int
+			for _, pd := range pairs {
+				var b int
+				for _, pr := range pd {
+					if pr.Destroyer == -1 {
+						b++
+					}
+				}
+				betti = append(betti, b)
+			}
+			expected := []int{1, 1, 1}
+			if p == 3 {
+				expected = []int{1, 0, 0}
+			}
+			if !reflect.DeepEqual(betti, expected) {
+				fmt.Printf("Algorithm %d with modulus %d gives Betti numbers %v, expected %v\n",
+					alg, p, betti, expected)
+				t.Fail()
+			}
+		}
+	}
+}
+
+// The pairs from the image filtration agree with the merge tree in
+// dimension zero, and with the cubical persistence in dimension one.
+func TestIsPrime(t *testing.T) {
+
+	var primes []int
+	for p := -3; p < 30; p++ {
+		if isPrime(p) {
+			primes = append(primes, p)
+		}
+	}
+
+	if !reflect.DeepEqual(primes, []int{2, 3, 5, 7, 11, 13, 17, 19, 23, 29}) {
+		fmt.Printf("Unexpected primes %v\n", primes)
+		t.Fail()
+	}
+}
+
+func TestReduceImage(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(92))
+
+	for jt := 0; jt < 10; jt++ {
+
+		rows, cols := 3+rng.Intn(8), 3+rng.Intn(8)
+		img := make([]int, rows*cols)
+		for i := range img {
+			img[i] = rng.Intn(10)
+		}
+
+		for _, alg := range allReductions {
+
+			pairs := NewImageBoundaryMatrix(img, rows).Reduce(ReduceOptions{Algorithm: alg})
+			if len(pairs) != 3 || len(pairs[2]) != 0 {
+				fmt.Printf("Test %d: unexpected pairs in dimension 2\n", jt)
+				t.Fail()
+				continue
+			}
+
+			var b1, d1, b2, d2 []float64
+			for _, pr := range pairs[0] {
+				if pr.Destroyer == -1 {
+					continue
+				}
+				b1 = append(b1, -pr.Birth)
+				d1 = append(d1, -pr.Death)
+			}
+			mt := NewMergeTree(img, rows)
+			nodes := mt.Nodes()
+			for _, nd := range nodes {
+				if nd.Type == MaxNode && nd.Death != -1 && nodes[nd.Death].Value != nd.Value {
+					b2 = append(b2, float64(nd.Value))
+					d2 = append(d2, float64(nodes[nd.Death].Value))
+				}
+			}
+			if !comparePairs(b1, d1, b2, d2) {
+				fmt.Printf("Test %d: H0 pairs differ from merge tree\n%v %v\n%v %v\n", jt, b1, d1, b2, d2)
+				t.Fail()
+			}
+
+			b1, d1, b2, d2 = nil, nil, nil, nil
+			for _, pr := range pairs[1] {
+				b1 = append(b1, -pr.Birth)
+				d1 = append(d1, -pr.Death)
+			}
+			for _, hp := range NewCubicalPersistence(img, rows).Pairs() {
+				b2 = append(b2, float64(img[hp.Saddle]))
+				d2 = append(d2, float64(img[hp.Bottom]))
+			}
+			if !comparePairs(b1, d1, b2, d2) {
+				fmt.Printf("Test %d: H1 pairs differ from cubical persistence\n%v %v\n%v %v\n", jt, b1, d1, b2, d2)
+				t.Fail()
+			}
+
+			if len(pairs[0]) == 0 || !math.IsInf(pairs[0][len(pairs[0])-1].Death, 1) {
+				fmt.Printf("Test %d: missing essential component\n", jt)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func TestBoundaryMatrix(t *testing.T) {
+
+	// A filled triangle
+	bm := NewBoundaryMatrix()
+	bm.AddColumn(0, 0, nil, nil)
+	bm.AddColumn(0, 0, nil, nil)
+	bm.AddColumn(0, 1, nil, nil)
+	bm.AddColumn(1, 2, []int{1, 0}, []int{1, -1})
+	bm.AddColumn(1, 3, []int{0, 2}, []int{-1, 1})
+	bm.AddColumn(1, 4, []int{1, 2}, []int{-1, 1})
+	bm.AddColumn(2, 5, []int{3, 4, 5}, []int{1, -1, 1})
+
+	if r, c := bm.Column(3); !reflect.DeepEqual(r, []int{0, 1}) || !reflect.DeepEqual(c, []int{-1, 1}) {
+		fmt.Printf("Unexpected column %v %v\n", r, c)
+		t.Fail()
+	}
+
+	for _, alg := range allReductions {
+		pairs := bm.Reduce(ReduceOptions{Algorithm: alg, Modulus: 5})
+		expected := [][]PersistencePair{
+			{
+				{Dim: 0, Birth: 0, Death: 2, Creator: 1, Destroyer: 3},
+				{Dim: 0, Birth: 1, Death: 3, Creator: 2, Destroyer: 4},
+				{Dim: 0, Birth: 0, Death: math.Inf(1), Creator: 0, Destroyer: -1},
+			},
+			{
+				{Dim: 1, Birth: 4, Death: 5, Creator: 5, Destroyer: 6},
+			},
+			nil,
+		}
+		if !reflect.DeepEqual(pairs, expected) {
+			fmt.Printf("Algorithm %d gives unexpected pairs:\n%v\n", alg, pairs)
+			t.Fail()
+		}
+	}
+}
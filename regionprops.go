@@ -0,0 +1,283 @@
+package tda
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// RegionProp contains descriptive properties of one labeled
+// component of an image.
+type RegionProp struct {
+
+	// The component label
+	Label int
+
+	// The number of pixels in the component
+	Area int
+
+	// A bounding box for the component
+	Bbox image.Rectangle
+
+	// The mean column (position 0) and row (position 1) of the
+	// pixels in the component
+	Centroid [2]float64
+
+	// The central second moments of the pixel positions: the
+	// variance of the columns, the variance of the rows, and the
+	// covariance of the columns and rows.
+	Moments [3]float64
+
+	// The angle in radians between the column axis and the major
+	// axis of the ellipse having the same second moments as the
+	// component.
+	Orientation float64
+
+	// The lengths of the major and minor axes of the ellipse
+	// having the same second moments as the component.
+	MajorAxis float64
+	MinorAxis float64
+
+	// The eccentricity of the ellipse having the same second
+	// moments as the component.
+	Eccentricity float64
+
+	// The number of pixel sides separating the component from
+	// other components or from the outside of the image.
+	Perimeter int
+
+	// The number of connected pieces of the component (always 1)
+	// minus the number of holes in it.
+	Euler int
+
+	// The area of the convex hull of the component's pixels, where
+	// each pixel is a unit square.
+	ConvexArea float64
+
+	// The ratio of Area to ConvexArea.
+	Solidity float64
+
+	// Summaries of the intensities of the component's pixels in
+	// the image passed to RegionProps.  These are zero if no
+	// image was provided.
+	IntensitySum  int
+	IntensityMin  int
+	IntensityMax  int
+	IntensityMean float64
+}
+
+// RegionProps returns descriptive properties for every labeled
+// component.  The properties for the component with label k are held
+// in position k of the returned slice (position 0 describes the
+// background).  If img is not nil it must have the same shape as the
+// labeled image, and is used to obtain the intensity summaries.
+//
+// The Euler number is obtained by counting 2x2 pixel patterns (Gray,
+// 1971), using the connectivity with which the image was labeled.
+func (la *Label) RegionProps(img []int) []RegionProp {
+
+	if img != nil && len(img) != len(la.labels) {
+		panic("img and the labeled image have different sizes")
+	}
+
+	r := la.rows
+	c := la.cols
+	props := make([]RegionProp, la.ncomp)
+	bf := make([]bool, la.ncomp)
+
+	// Raw moments of the pixel positions
+	mom := make([][5]float64, la.ncomp)
+
+	// The corners of the first and last pixel in each run of
+	// pixels having the same label, used to find the convex hulls.
+	hpts := make([][][2]float64, la.ncomp)
+
+	// label returns the label at a position, treating positions
+	// outside of the image as background.
+	label := func(i, j int) int {
+		if i < 0 || i >= r || j < 0 || j >= c {
+			return 0
+		}
+		return la.labels[i*c+j]
+	}
+
+	// Counts of 2x2 patterns for the Euler numbers
+	q1 := make([]int, la.ncomp)
+	q3 := make([]int, la.ncomp)
+	qd := make([]int, la.ncomp)
+
+	for i := -1; i < r; i++ {
+		for j := -1; j < c; j++ {
+
+			// Count the 2x2 patterns with upper left corner
+			// at (i, j).
+			var w [4]int
+			w[0], w[1], w[2], w[3] = label(i, j), label(i, j+1), label(i+1, j), label(i+1, j+1)
+			for k, v := range w {
+				if v == 0 {
+					continue
+				}
+				var n int
+				for kk, u := range w {
+					if u == v {
+						if kk < k {
+							// Already counted
+							n = -1
+							break
+						}
+						n++
+					}
+				}
+				switch {
+				case n == 1:
+					q1[v]++
+				case n == 3:
+					q3[v]++
+				case n == 2 && ((w[0] == v && w[3] == v) || (w[1] == v && w[2] == v)):
+					qd[v]++
+				}
+			}
+
+			if i < 0 || j < 0 {
+				continue
+			}
+
+			l := la.labels[i*c+j]
+			p := &props[l]
+			x, y := float64(j), float64(i)
+
+			if !bf[l] {
+				bf[l] = true
+				p.Label = l
+				p.Bbox = image.Rect(j, i, j+1, i+1)
+				if img != nil {
+					p.IntensityMin = img[i*c+j]
+					p.IntensityMax = img[i*c+j]
+				}
+			} else {
+				p.Bbox = p.Bbox.Union(image.Rect(j, i, j+1, i+1))
+			}
+
+			p.Area++
+			m := &mom[l]
+			m[0] += x
+			m[1] += y
+			m[2] += x * x
+			m[3] += y * y
+			m[4] += x * y
+
+			if img != nil {
+				v := img[i*c+j]
+				p.IntensitySum += v
+				if v < p.IntensityMin {
+					p.IntensityMin = v
+				}
+				if v > p.IntensityMax {
+					p.IntensityMax = v
+				}
+			}
+
+			// Count the sides shared with other components
+			for _, d := range [4][2]int{{-1, 0}, {1, 0}, {0, -1}, {0, 1}} {
+				if i+d[0] < 0 || i+d[0] >= r || j+d[1] < 0 || j+d[1] >= c {
+					if l != 0 {
+						p.Perimeter++
+					}
+				} else if la.labels[(i+d[0])*c+j+d[1]] != l {
+					p.Perimeter++
+				}
+			}
+
+			// Keep the pixel corners at the ends of runs
+			if j == 0 || la.labels[i*c+j-1] != l {
+				hpts[l] = append(hpts[l], [2]float64{x, y}, [2]float64{x, y + 1})
+			}
+			if j == c-1 || la.labels[i*c+j+1] != l {
+				hpts[l] = append(hpts[l], [2]float64{x + 1, y}, [2]float64{x + 1, y + 1})
+			}
+		}
+	}
+
+	for l := range props {
+
+		p := &props[l]
+		if p.Area == 0 {
+			continue
+		}
+		n := float64(p.Area)
+		m := mom[l]
+
+		p.Centroid = [2]float64{m[0] / n, m[1] / n}
+		vxx := m[2]/n - p.Centroid[0]*p.Centroid[0]
+		vyy := m[3]/n - p.Centroid[1]*p.Centroid[1]
+		vxy := m[4]/n - p.Centroid[0]*p.Centroid[1]
+		p.Moments = [3]float64{vxx, vyy, vxy}
+
+		// Eigenvalues of the covariance matrix
+		tr := (vxx + vyy) / 2
+		dd := math.Sqrt(math.Max(0, (vxx-vyy)*(vxx-vyy)/4+vxy*vxy))
+		l1, l2 := tr+dd, math.Max(0, tr-dd)
+		p.MajorAxis = 4 * math.Sqrt(l1)
+		p.MinorAxis = 4 * math.Sqrt(l2)
+		p.Orientation = math.Atan2(2*vxy, vxx-vyy) / 2
+		if l1 > 0 {
+			p.Eccentricity = math.Sqrt(1 - l2/l1)
+		}
+
+		if la.conn == 4 {
+			p.Euler = (q1[l] - q3[l] + 2*qd[l]) / 4
+		} else {
+			p.Euler = (q1[l] - q3[l] - 2*qd[l]) / 4
+		}
+
+		p.ConvexArea = hullArea(hpts[l])
+		p.Solidity = n / p.ConvexArea
+
+		if img != nil {
+			p.IntensityMean = float64(p.IntensitySum) / n
+		}
+	}
+
+	return props
+}
+
+// hullArea returns the area of the convex hull of a set of points,
+// using the monotone chain algorithm.
+func hullArea(pts [][2]float64) float64 {
+
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i][0] != pts[j][0] {
+			return pts[i][0] < pts[j][0]
+		}
+		return pts[i][1] < pts[j][1]
+	})
+
+	cross := func(o, a, b [2]float64) float64 {
+		return (a[0]-o[0])*(b[1]-o[1]) - (a[1]-o[1])*(b[0]-o[0])
+	}
+
+	var hull [][2]float64
+	for pass := 0; pass < 2; pass++ {
+		start := len(hull)
+		for _, q := range pts {
+			for len(hull) >= start+2 && cross(hull[len(hull)-2], hull[len(hull)-1], q) <= 0 {
+				hull = hull[0 : len(hull)-1]
+			}
+			hull = append(hull, q)
+		}
+		hull = hull[0 : len(hull)-1]
+
+		// Reverse the points for the upper hull
+		for i, j := 0, len(pts)-1; i < j; i, j = i+1, j-1 {
+			pts[i], pts[j] = pts[j], pts[i]
+		}
+	}
+
+	var area float64
+	for i := range hull {
+		j := (i + 1) % len(hull)
+		area += hull[i][0]*hull[j][1] - hull[j][0]*hull[i][1]
+	}
+
+	return math.Abs(area) / 2
+}
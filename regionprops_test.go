@@ -0,0 +1,107 @@
+package tda
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"testing"
+)
+
+func TestRegionProps(t *testing.T) {
+
+	mask := unpack([]string{
+		"0000000",
+		"0111000",
+		"0101000",
+		"0111000",
+		"0000011",
+		"0000000",
+	})
+
+	img := make([]int, len(mask))
+	for i := range img {
+		img[i] = i
+	}
+
+	a := 4 * math.Sqrt(0.75)
+	expected := []RegionProp{
+		{
+			Label:        1,
+			Area:         8,
+			Bbox:         image.Rect(1, 1, 4, 4),
+			Centroid:     [2]float64{2, 2},
+			Moments:      [3]float64{0.75, 0.75, 0},
+			MajorAxis:    a,
+			MinorAxis:    a,
+			Perimeter:    16,
+			Euler:        0,
+			ConvexArea:   9,
+			Solidity:     8.0 / 9.0,
+			IntensitySum: 8 + 9 + 10 + 15 + 17 + 22 + 23 + 24,
+			IntensityMin: 8,
+			IntensityMax: 24,
+
+			IntensityMean: 16,
+		},
+		{
+			Label:         2,
+			Area:          2,
+			Bbox:          image.Rect(5, 4, 7, 5),
+			Centroid:      [2]float64{5.5, 4},
+			Moments:       [3]float64{0.25, 0, 0},
+			MajorAxis:     2,
+			Eccentricity:  1,
+			Perimeter:     6,
+			Euler:         1,
+			ConvexArea:    2,
+			Solidity:      1,
+			IntensitySum:  67,
+			IntensityMin:  33,
+			IntensityMax:  34,
+			IntensityMean: 33.5,
+		},
+	}
+
+	for _, conn := range []int{4, 8} {
+
+		la := NewLabelPreserve(mask, 6, conn, nil)
+		props := la.RegionProps(img)
+
+		if len(props) != 3 {
+			fmt.Printf("Found %d regions, expected 3\n", len(props))
+			t.Fail()
+			continue
+		}
+
+		for k, e := range expected {
+			p := props[k+1]
+			if !compareRegionProp(p, e) {
+				fmt.Printf("Region %d with conn=%d does not match\n", k+1, conn)
+				fmt.Printf("Got:\n%+v\n", p)
+				fmt.Printf("Expected:\n%+v\n", e)
+				t.Fail()
+			}
+		}
+	}
+}
+
+func compareRegionProp(x, y RegionProp) bool {
+
+	tol := 1e-10
+	fx := []float64{x.Centroid[0], x.Centroid[1], x.Moments[0], x.Moments[1],
+		x.Moments[2], x.Orientation, x.MajorAxis, x.MinorAxis, x.Eccentricity,
+		x.ConvexArea, x.Solidity, x.IntensityMean}
+	fy := []float64{y.Centroid[0], y.Centroid[1], y.Moments[0], y.Moments[1],
+		y.Moments[2], y.Orientation, y.MajorAxis, y.MinorAxis, y.Eccentricity,
+		y.ConvexArea, y.Solidity, y.IntensityMean}
+	for i := range fx {
+		if math.Abs(fx[i]-fy[i]) > tol {
+			return false
+		}
+	}
+
+	return x.Label == y.Label && x.Area == y.Area && x.Bbox == y.Bbox &&
+		x.Perimeter == y.Perimeter && x.Euler == y.Euler &&
+		x.IntensitySum == y.IntensitySum && x.IntensityMin == y.IntensityMin &&
+		x.IntensityMax == y.IntensityMax
+}
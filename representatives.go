@@ -0,0 +1,264 @@
+package tda
+
+import (
+	"sort"
+
+	"github.com/theodesp/unionfind"
+)
+
+// Cycle is a representative cycle for a persistence pair, given as a
+// linear combination of the cells of a boundary matrix.
+type Cycle struct {
+
+	// The persistence pair represented by the cycle
+	Pair PersistencePair
+
+	// The positions of the cells in the cycle, in increasing order
+	Cells []int
+
+	// The coefficient of each cell in the cycle, in 1, ..., p-1
+	Coefs []int
+
+	// The points (vertices or pixels) that make up the cells in the
+	// cycle, in increasing order, see BoundaryMatrix.Support
+	Points []int
+}
+
+// Cycles returns a representative cycle for each persistence pair of
+// the boundary matrix, with coefficients in the integers modulo the
+// given prime (Z/2 if modulus is zero).  The cycles are indexed by
+// dimension, and are in the same order as the pairs returned by
+// Reduce.  The cycle of a pair that dies is the boundary of a chain
+// that fills it in at the death value, and the cycle of an essential
+// class is a cycle that is present at the end of the filtration.  In
+// both cases the creator is the last cell of the cycle.
+func (bm *BoundaryMatrix) Cycles(modulus int) [][]Cycle {
+
+	p := modulus
+	if p == 0 {
+		p = 2
+	}
+	if !isPrime(p) {
+		panic("Modulus must be a prime number")
+	}
+
+	n := len(bm.rows)
+	cols := bm.columns(p)
+
+	// The standard reduction, maintaining the matrix V for which
+	// the reduced matrix is the product of the boundary matrix and
+	// V.  Each column of V starts as the corresponding unit vector.
+	low := make([]int, n)
+	pivot := make([]int, n)
+	v := make([]sparseColumn, n)
+	for j := range low {
+		low[j] = -1
+		pivot[j] = -1
+		v[j] = sparseColumn{rows: []int{j}, vals: []int{1}}
+	}
+
+	var buf sparseColumn
+	for j := range cols {
+		col := sparseColumn{
+			rows: append([]int(nil), cols[j].rows...),
+			vals: append([]int(nil), cols[j].vals...),
+		}
+		for len(col.rows) > 0 {
+			k := len(col.rows) - 1
+			i := col.rows[k]
+			q := pivot[i]
+			if q == -1 {
+				break
+			}
+			qc := cols[q]
+			f := col.vals[k] * modInverse(qc.vals[len(qc.vals)-1], p) % p
+			buf = subColumn(col, qc, f, p, buf)
+			col, buf = buf, col
+			buf = subColumn(v[j], v[q], f, p, buf)
+			v[j], buf = buf, sparseColumn{}
+		}
+		cols[j] = col
+		if len(col.rows) > 0 {
+			i := col.rows[len(col.rows)-1]
+			low[j] = i
+			pivot[i] = j
+		}
+	}
+
+	pairs := bm.pairs(low, bm.maxDim())
+	cycles := make([][]Cycle, len(pairs))
+	for d, pd := range pairs {
+		for _, pr := range pd {
+			var c sparseColumn
+			if pr.Destroyer != -1 {
+				c = cols[pr.Destroyer]
+			} else {
+				c = v[pr.Creator]
+			}
+			cycles[d] = append(cycles[d], Cycle{
+				Pair:   pr,
+				Cells:  c.rows,
+				Coefs:  c.vals,
+				Points: bm.points(c.rows),
+			})
+		}
+	}
+
+	return cycles
+}
+
+// points returns the union of the supports of the given cells, in
+// increasing order.
+func (bm *BoundaryMatrix) points(cells []int) []int {
+
+	var pts []int
+	for _, j := range cells {
+		pts = append(pts, bm.support[j]...)
+	}
+	if len(pts) == 0 {
+		return nil
+	}
+
+	sort.Ints(pts)
+	i := 0
+	for _, x := range pts {
+		if i == 0 || x != pts[i-1] {
+			pts[i] = x
+			i++
+		}
+	}
+
+	return pts[0:i]
+}
+
+// findState returns the position of the state with the given step in
+// trajectory k, or -1 if the trajectory has no state at that step.
+func (ps *Persistence) findState(k, step int) int {
+	tr := ps.traj[k]
+	j := sort.Search(len(tr), func(j int) bool { return tr[j].Step >= step })
+	if j < len(tr) && tr[j].Step == step {
+		return j
+	}
+	return -1
+}
+
+// Pixels returns the pixels (as linear indices, in increasing order)
+// of the object described by state j of trajectory k.  Since the
+// labels are not retained across steps, the object is found by
+// labeling the image thresholded at the threshold of the state.  The
+// pixels can be used to overlay the object on the image.
+func (ps *Persistence) Pixels(k, j int) []int {
+
+	st := ps.traj[k][j]
+	timg := threshold(ps.img, nil, st.Threshold)
+	lbl := labelImage(timg, ps.rows, ps.conn, ps.workers, nil)
+	labels := lbl.Labels()
+	sizes := lbl.Sizes(nil)
+	bboxes := lbl.Bboxes(nil)
+
+	// Find the component with the same bounding box, size and
+	// maximum as the state.
+	l := 0
+	for r := st.Bbox.Min.Y; r < st.Bbox.Max.Y && l == 0; r++ {
+		for c := st.Bbox.Min.X; c < st.Bbox.Max.X; c++ {
+			i := r*ps.cols + c
+			m := labels[i]
+			if m != 0 && ps.img[i] == st.Max && sizes[m] == st.Size && bboxes[m] == st.Bbox {
+				l = m
+				break
+			}
+		}
+	}
+	if l == 0 {
+		panic("object not found")
+	}
+
+	var pix []int
+	for i, m := range labels {
+		if m == l {
+			pix = append(pix, i)
+		}
+	}
+
+	return pix
+}
+
+// brightest returns the brightest of the given pixels, taking the
+// first one in case of ties.
+func (ps *Persistence) brightest(pix []int) int {
+	b := pix[0]
+	for _, i := range pix[1:] {
+		if ps.img[i] > ps.img[b] {
+			b = i
+		}
+	}
+	return b
+}
+
+// CriticalPixels returns the pixels (as linear indices) at which the
+// object described by trajectory k is born and dies.  The object dies
+// at its brightest pixel in the last state of the trajectory, which
+// is the last of its pixels to remain as the threshold increases.  If
+// the trajectory branched from a parent trajectory, the object is born
+// at the saddle pixel that joins it to the parent object in the
+// direction of decreasing threshold, which is the pixel of the parent
+// object at the preceding step whose removal separates the brightest
+// pixels of the two objects.  If the trajectory began at the first
+// step, the birth pixel is -1.
+func (ps *Persistence) CriticalPixels(k int) (int, int) {
+
+	tr := ps.traj[k]
+	death := ps.brightest(ps.Pixels(k, len(tr)-1))
+
+	br := ps.branches[k]
+	if br.Parent == -1 {
+		return -1, death
+	}
+
+	// The brightest pixels of the object and of the parent object
+	// when the object first appears
+	p1 := ps.brightest(ps.Pixels(k, 0))
+	j := ps.findState(br.Parent, br.Step)
+	if j == -1 {
+		panic("parent trajectory does not continue through the split")
+	}
+	p2 := ps.brightest(ps.Pixels(br.Parent, j))
+
+	// Add the pixels of the parent object at the preceding step in
+	// order of decreasing intensity, until the two brightest
+	// pixels are connected.
+	j = ps.findState(br.Parent, br.Step-1)
+	if j == -1 {
+		panic("parent trajectory is not defined before the split")
+	}
+	pix := ps.Pixels(br.Parent, j)
+	sort.SliceStable(pix, func(a, b int) bool {
+		return ps.img[pix[a]] > ps.img[pix[b]]
+	})
+
+	uf := unionfind.New(len(ps.img))
+	added := make(map[int]bool)
+	for _, q := range pix {
+		added[q] = true
+		r, c := q/ps.cols, q%ps.cols
+		for dr := -1; dr <= 1; dr++ {
+			for dc := -1; dc <= 1; dc++ {
+				if (dr == 0 && dc == 0) || (ps.conn == 4 && dr != 0 && dc != 0) {
+					continue
+				}
+				r1, c1 := r+dr, c+dc
+				if r1 < 0 || r1 >= ps.rows || c1 < 0 || c1 >= ps.cols {
+					continue
+				}
+				if u := r1*ps.cols + c1; added[u] {
+					uf.Union(q, u)
+				}
+			}
+		}
+		if added[p1] && added[p2] && uf.Find(p1) == uf.Find(p2) {
+			return q, death
+		}
+	}
+
+	panic("objects are not connected")
+}
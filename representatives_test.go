@@ -0,0 +1,291 @@
+package tda
+
+import (
+	"fmt"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// checkCycle returns an error message if the chain is not a cycle over
+// Z/p representing the pair, or an empty string.
+func checkCycle(bm *BoundaryMatrix, c Cycle, p int) string {
+
+	if len(c.Cells) == 0 || c.Cells[len(c.Cells)-1] != c.Pair.Creator {
+		return "creator is not the last cell"
+	}
+
+	bd := make(map[int]int)
+	for k, j := range c.Cells {
+		if bm.Dim(j) != c.Pair.Dim {
+			return "cell has the wrong dimension"
+		}
+		rows, coefs := bm.Column(j)
+		for i, r := range rows {
+			bd[r] = (bd[r] + c.Coefs[k]*coefs[i]) % p
+		}
+	}
+	for _, v := range bd {
+		if (v%p+p)%p != 0 {
+			return "chain has a nonzero boundary"
+		}
+	}
+
+	return ""
+}
+
+func TestCycles(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(101))
+
+	for jt := 0; jt < 10; jt++ {
+
+		var bm *BoundaryMatrix
+		if jt%2 == 0 {
+			n := 10 + rng.Intn(10)
+			x := make([]float64, 2*n)
+			for i := range x {
+				x[i] = rng.Float64()
+			}
+			bm = NewRips(x, 2, 0.6, 2).BoundaryMatrix()
+		} else {
+			rows, cols := 3+rng.Intn(6), 3+rng.Intn(6)
+			img := make([]int, rows*cols)
+			for i := range img {
+				img[i] = rng.Intn(10)
+			}
+			bm = NewImageBoundaryMatrix(img, rows)
+		}
+
+		for _, p := range []int{2, 3} {
+			pairs := bm.Reduce(ReduceOptions{Modulus: p})
+			cycles := bm.Cycles(p)
+			if len(cycles) != len(pairs) {
+				fmt.Printf("Test %d: found cycles in %d dimensions, expected %d\n", jt, len(cycles), len(pairs))
+				t.Fail()
+				continue
+			}
+			for d := range cycles {
+				if len(cycles[d]) != len(pairs[d]) {
+					fmt.Printf("Test %d: found %d cycles in dimension %d, expected %d\n",
+						jt, len(cycles[d]), d, len(pairs[d]))
+					t.Fail()
+					continue
+				}
+				for k, c := range cycles[d] {
+					if c.Pair != pairs[d][k] {
+						fmt.Printf("Test %d: cycle %d has pair %v, expected %v\n", jt, k, c.Pair, pairs[d][k])
+						t.Fail()
+					}
+					if msg := checkCycle(bm, c, p); msg != "" {
+						fmt.Printf("Test %d: cycle %d in dimension %d: %s\n", jt, k, d, msg)
+						t.Fail()
+					}
+				}
+			}
+		}
+	}
+}
+
+// Coefficients must be in a field, so a composite modulus is rejected
+// when finding cycles, as when reducing.
+func TestCyclesModulus(t *testing.T) {
+
+	st := NewSimplexTree()
+	st.Insert([]int{0, 1, 2}, 1)
+	bm := st.BoundaryMatrix()
+
+	for _, p := range []int{-1, 1, 4, 9} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					fmt.Printf("Modulus %d was accepted\n", p)
+					t.Fail()
+				}
+			}()
+			bm.Cycles(p)
+		}()
+	}
+
+	for _, p := range []int{0, 2, 3, 5} {
+		if c := bm.Cycles(p); len(c) == 0 {
+			fmt.Printf("No cycles with modulus %d\n", p)
+			t.Fail()
+		}
+	}
+}
+
+func TestCyclesImage(t *testing.T) {
+
+	img := []int{
+		0, 0, 0, 0, 0,
+		0, 5, 5, 5, 0,
+		0, 5, 1, 3, 0,
+		0, 5, 5, 5, 0,
+		0, 0, 0, 0, 0,
+	}
+
+	bm := NewImageBoundaryMatrix(img, 5)
+	cycles := bm.Cycles(0)
+	if len(cycles[1]) != 1 {
+		fmt.Printf("Found %d loops, expected 1\n", len(cycles[1]))
+		t.FailNow()
+	}
+
+	c := cycles[1][0]
+	if c.Pair.Birth != -3 || c.Pair.Death != -1 {
+		fmt.Printf("Unexpected pair %v\n", c.Pair)
+		t.Fail()
+	}
+	if s := bm.Support(c.Pair.Creator); !reflect.DeepEqual(s, []int{13}) {
+		fmt.Printf("Creator has support %v, expected [13]\n", s)
+		t.Fail()
+	}
+	if s := bm.Support(c.Pair.Destroyer); !reflect.DeepEqual(s, []int{12}) {
+		fmt.Printf("Destroyer has support %v, expected [12]\n", s)
+		t.Fail()
+	}
+
+	// The loop surrounds the bottom of the hole, through pixels
+	// that are present when it is born.
+	var found bool
+	for _, i := range c.Points {
+		if i == 12 || img[i] < 3 {
+			fmt.Printf("Loop passes through pixel %d\n", i)
+			t.Fail()
+		}
+		if i == 13 {
+			found = true
+		}
+	}
+	if !found {
+		fmt.Printf("Loop does not pass through the saddle\n")
+		t.Fail()
+	}
+
+	// The vertices of a simplex are its support.
+	st := NewSimplexTree()
+	st.Insert([]int{0, 1, 2}, 1)
+	bm = st.BoundaryMatrix()
+	if s := bm.Support(bm.NumColumns() - 1); !reflect.DeepEqual(s, []int{0, 1, 2}) {
+		fmt.Printf("Triangle has support %v\n", s)
+		t.Fail()
+	}
+}
+
+func TestPersistencePixels(t *testing.T) {
+
+	img := []int{
+		0, 0, 0, 0, 0, 0, 0,
+		0, 9, 5, 3, 6, 8, 0,
+		0, 0, 0, 0, 0, 0, 0,
+	}
+
+	ps := NewPersistence(img, 3, 10)
+	ps.Sort()
+	traj := ps.Trajectories()
+	if len(traj) != 2 {
+		fmt.Printf("Found %d trajectories, expected 2\n", len(traj))
+		t.FailNow()
+	}
+
+	if pix := ps.Pixels(0, 0); len(pix) != len(img) {
+		fmt.Printf("Object has %d pixels at the first step, expected %d\n", len(pix), len(img))
+		t.Fail()
+	}
+	if pix := ps.Pixels(1, 0); !reflect.DeepEqual(pix, []int{11, 12}) {
+		fmt.Printf("Unexpected pixels %v\n", pix)
+		t.Fail()
+	}
+
+	if b, d := ps.CriticalPixels(0); b != -1 || d != 8 {
+		fmt.Printf("Trajectory 0 has critical pixels %d, %d, expected -1, 8\n", b, d)
+		t.Fail()
+	}
+	if b, d := ps.CriticalPixels(1); b != 10 || d != 12 {
+		fmt.Printf("Trajectory 1 has critical pixels %d, %d, expected 10, 12\n", b, d)
+		t.Fail()
+	}
+}
+
+// The representatives are also defined for exact persistence, in
+// which the object splits at the first threshold above the saddle.
+func TestExactPersistencePixels(t *testing.T) {
+
+	img := []int{
+		0, 0, 0, 0, 0, 0, 0,
+		0, 9, 5, 3, 6, 8, 0,
+		0, 0, 0, 0, 0, 0, 0,
+	}
+
+	ps := NewExactPersistence(img, 3)
+	ps.Sort()
+	if n := len(ps.Trajectories()); n != 2 {
+		fmt.Printf("Found %d trajectories, expected 2\n", n)
+		t.FailNow()
+	}
+
+	if pix := ps.Pixels(1, 0); !reflect.DeepEqual(pix, []int{11, 12}) {
+		fmt.Printf("Unexpected pixels %v\n", pix)
+		t.Fail()
+	}
+	if b, d := ps.CriticalPixels(0); b != -1 || d != 8 {
+		fmt.Printf("Trajectory 0 has critical pixels %d, %d, expected -1, 8\n", b, d)
+		t.Fail()
+	}
+	if b, d := ps.CriticalPixels(1); b != 10 || d != 12 {
+		fmt.Printf("Trajectory 1 has critical pixels %d, %d, expected 10, 12\n", b, d)
+		t.Fail()
+	}
+}
+
+func TestPersistencePixelsRandom(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(102))
+
+	for jt := 0; jt < 10; jt++ {
+
+		rows, cols := 5+rng.Intn(10), 5+rng.Intn(10)
+		img := make([]int, rows*cols)
+		for i := range img {
+			img[i] = rng.Intn(20)
+		}
+
+		ps := NewPersistence(img, rows, 10)
+		ps.Sort()
+		for k, tr := range ps.Trajectories() {
+			for j, st := range tr {
+				pix := ps.Pixels(k, j)
+				mx := 0
+				for _, i := range pix {
+					if img[i] < st.Threshold {
+						fmt.Printf("Test %d: pixel %d is below the threshold\n", jt, i)
+						t.Fail()
+					}
+					if img[i] > mx {
+						mx = img[i]
+					}
+				}
+				if len(pix) != st.Size || mx != st.Max {
+					fmt.Printf("Test %d: state %d of trajectory %d has the wrong pixels\n", jt, j, k)
+					t.Fail()
+				}
+			}
+
+			b, d := ps.CriticalPixels(k)
+			if img[d] != tr[len(tr)-1].Max {
+				fmt.Printf("Test %d: death pixel of trajectory %d is not the maximum\n", jt, k)
+				t.Fail()
+			}
+			br := ps.Branches()[k]
+			if (b == -1) != (br.Parent == -1) {
+				fmt.Printf("Test %d: trajectory %d has birth pixel %d\n", jt, k, b)
+				t.Fail()
+			}
+			if b != -1 && img[b] >= tr[0].Threshold {
+				fmt.Printf("Test %d: birth pixel of trajectory %d is above the threshold\n", jt, k)
+				t.Fail()
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package tda
+
+import (
+	"math"
+	"sort"
+)
+
+// PersistencePair describes the lifespan of a homology class in a
+// filtration of a simplicial complex.
+type PersistencePair struct {
+
+	// The dimension of the homology class (0 for connected
+	// components, 1 for loops, 2 for voids)
+	Dim int
+
+	// The filtration values at which the class is born and dies
+	Birth float64
+	Death float64
+
+	// The positions in the filtration of the simplex that creates
+	// the class, and of the simplex that destroys it.  Destroyer
+	// is -1 if the class persists to the end of the filtration.
+	Creator   int
+	Destroyer int
+}
+
+// Rips supports calculation of the persistent homology of the
+// Vietoris-Rips filtration of a finite metric space, such as a point
+// cloud.  A set of points forms a simplex of the Rips complex at scale
+// r when all pairwise distances among the points are at most r, so
+// the filtration value of a simplex is the greatest distance between
+// two of its vertices.
+type Rips struct {
+
+	// The number of points
+	n int
+
+	// The n x n distance matrix, in row-major order
+	dist []float64
+
+	// The greatest filtration value that is included
+	maxRadius float64
+
+	// The greatest homology dimension that is calculated
+	maxDim int
+
+	// The simplices, in filtration order, each given as an
+	// increasing sequence of vertices
+	simplices [][]int
+
+	// The filtration value of each simplex
+	values []float64
+
+	// The persistence pairs, including classes that never die
+	pairs []PersistencePair
+}
+
+// NewRips calculates the persistent homology of the Rips filtration
+// of a point cloud, using Euclidean distances.  The n x d matrix x
+// holds the coordinates of n points in d dimensions, in row-major
+// order.  Only simplices with filtration values no greater than
+// maxRadius are included, and homology is calculated in dimensions 0
+// through maxDim.  Use math.Inf(1) for maxRadius to include all
+// simplices.
+func NewRips(x []float64, d int, maxRadius float64, maxDim int) *Rips {
+
+	n := len(x) / d
+	if n*d != len(x) {
+		panic("d is not compatible with x")
+	}
+
+	dist := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < i; j++ {
+			var s float64
+			for k := 0; k < d; k++ {
+				u := x[i*d+k] - x[j*d+k]
+				s += u * u
+			}
+			s = math.Sqrt(s)
+			dist[i*n+j] = s
+			dist[j*n+i] = s
+		}
+	}
+
+	return NewRipsDist(dist, n, maxRadius, maxDim)
+}
+
+// NewRipsDist calculates the persistent homology of the Rips
+// filtration of a finite metric space, given its n x n distance
+// matrix in row-major order.  The remaining arguments are as in
+// NewRips.
+func NewRipsDist(dist []float64, n int, maxRadius float64, maxDim int) *Rips {
+
+	if len(dist) != n*n {
+		panic("dist is not an n x n matrix")
+	}
+
+	if maxDim < 0 {
+		panic("maxDim must be non-negative")
+	}
+
+	rp := &Rips{
+		n:         n,
+		dist:      dist,
+		maxRadius: maxRadius,
+		maxDim:    maxDim,
+	}
+
+	rp.build()
+	rp.reduce()
+
+	return rp
+}
+
+// build enumerates the simplices of the Rips complex up to dimension
+// maxDim+1, which are the cliques of the neighborhood graph, and
+// places them in filtration order.
+func (rp *Rips) build() {
+
+	n := rp.n
+	dist := rp.dist
+
+	var simplices [][]int
+	var values []float64
+
+	// Extend each simplex with vertices greater than its last
+	// vertex that are within maxRadius of all its vertices.
+	var extend func(s []int, v float64)
+	extend = func(s []int, v float64) {
+		simplices = append(simplices, s)
+		values = append(values, v)
+		if len(s) > rp.maxDim+1 {
+			return
+		}
+		for u := s[len(s)-1] + 1; u < n; u++ {
+			w := v
+			for _, t := range s {
+				w = math.Max(w, dist[u*n+t])
+			}
+			if w <= rp.maxRadius {
+				s1 := make([]int, len(s)+1)
+				copy(s1, s)
+				s1[len(s)] = u
+				extend(s1, w)
+			}
+		}
+	}
+	for u := 0; u < n; u++ {
+		extend([]int{u}, 0)
+	}
+
+	// Order by filtration value, placing faces before their
+	// cofaces when the values are equal.
+	ord := make([]int, len(simplices))
+	for i := range ord {
+		ord[i] = i
+	}
+	sort.SliceStable(ord, func(i, j int) bool {
+		si, sj := simplices[ord[i]], simplices[ord[j]]
+		vi, vj := values[ord[i]], values[ord[j]]
+		if vi != vj {
+			return vi < vj
+		}
+		return len(si) < len(sj)
+	})
+
+	rp.simplices = make([][]int, len(ord))
+	rp.values = make([]float64, len(ord))
+	for k, i := range ord {
+		rp.simplices[k] = simplices[i]
+		rp.values[k] = values[i]
+	}
+}
+
+// simplexKey returns a key that uniquely identifies a simplex among
+// the simplices of the same dimension, using the combinatorial number
+// system.
+func simplexKey(s []int) int {
+	var key int
+	for i, v := range s {
+		key += binomial(v, i+1)
+	}
+	return key
+}
+
+// binomial returns n choose k.
+func binomial(n, k int) int {
+	if k > n {
+		return 0
+	}
+	r := 1
+	for i := 1; i <= k; i++ {
+		r = r * (n - k + i) / i
+	}
+	return r
+}
+
+// reduce obtains the persistence pairs of the filtration.
+func (rp *Rips) reduce() {
+
+	// The classes that never die persist to the end of the
+	// filtration.
+	end := rp.maxRadius
+	if math.IsInf(end, 1) && len(rp.values) > 0 {
+		end = rp.values[len(rp.values)-1]
+	}
+
+	rp.pairs = filtrationPairs(rp.simplices, rp.values, rp.maxDim, end)
+}
+
+// Pairs returns the persistence pairs in the given dimension.  Pairs
+// in which the birth and death values are equal are omitted.
+func (rp *Rips) Pairs(dim int) []PersistencePair {
+
+	var pairs []PersistencePair
+	for _, pr := range rp.pairs {
+		if pr.Dim == dim {
+			pairs = append(pairs, pr)
+		}
+	}
+
+	return pairs
+}
+
+// BirthDeath returns the birth and death values of the homology
+// classes in the given dimension, in the same form as
+// Persistence.BirthDeath.  Classes that never die are given the death
+// value maxRadius, or the greatest filtration value if maxRadius is
+// infinite.  The results can be passed to NewLandscape or
+// NewConvexPeel.
+func (rp *Rips) BirthDeath(dim int) ([]float64, []float64) {
+
+	var birth, death []float64
+	for _, pr := range rp.pairs {
+		if pr.Dim == dim {
+			birth = append(birth, pr.Birth)
+			death = append(death, pr.Death)
+		}
+	}
+
+	return birth, death
+}
+
+// NumSimplices returns the number of simplices in the filtration.
+func (rp *Rips) NumSimplices() int {
+	return len(rp.simplices)
+}
+
+// Simplex returns the vertices and filtration value of the simplex at
+// position k in the filtration.
+func (rp *Rips) Simplex(k int) ([]int, float64) {
+	return rp.simplices[k], rp.values[k]
+}
+
+// BoundaryMatrix returns the boundary matrix of the filtration, which
+// can be reduced with other options.
+func (rp *Rips) BoundaryMatrix() *BoundaryMatrix {
+	return NewSimplicialBoundaryMatrix(rp.simplices, rp.values)
+}
@@ -0,0 +1,172 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestRipsSquare(t *testing.T) {
+
+	// The corners of the unit square
+	x := []float64{0, 0, 1, 0, 1, 1, 0, 1}
+	rp := NewRips(x, 2, math.Inf(1), 1)
+
+	b0, d0 := rp.BirthDeath(0)
+	if !floats.Equal(b0, []float64{0, 0, 0, 0}) || !floats.EqualApprox(d0, []float64{1, 1, 1, math.Sqrt2}, 1e-10) {
+		fmt.Printf("Unexpected H0 pairs %v %v\n", b0, d0)
+		t.Fail()
+	}
+
+	b1, d1 := rp.BirthDeath(1)
+	if !floats.Equal(b1, []float64{1}) || !floats.EqualApprox(d1, []float64{math.Sqrt2}, 1e-10) {
+		fmt.Printf("Unexpected H1 pairs %v %v\n", b1, d1)
+		t.Fail()
+	}
+
+	// The loop never dies if the diagonals are excluded
+	rp = NewRips(x, 2, 1.2, 1)
+	b1, d1 = rp.BirthDeath(1)
+	pr := rp.Pairs(1)
+	if !floats.Equal(b1, []float64{1}) || !floats.Equal(d1, []float64{1.2}) || len(pr) != 1 || pr[0].Destroyer != -1 {
+		fmt.Printf("Unexpected H1 pairs %v %v\n", b1, d1)
+		t.Fail()
+	}
+	if s, v := rp.Simplex(pr[0].Creator); len(s) != 2 || v != 1 {
+		fmt.Printf("Unexpected creator %v %v\n", s, v)
+		t.Fail()
+	}
+}
+
+func TestRipsHexagon(t *testing.T) {
+
+	// The vertices of a regular hexagon with unit sides.  At scale
+	// sqrt(3) the Rips complex is an octahedron, which encloses a
+	// void that is filled at scale 2.
+	var x []float64
+	for k := 0; k < 6; k++ {
+		a := float64(k) * math.Pi / 3
+		x = append(x, math.Cos(a), math.Sin(a))
+	}
+	rp := NewRips(x, 2, math.Inf(1), 2)
+
+	b1, d1 := rp.BirthDeath(1)
+	if !floats.EqualApprox(b1, []float64{1}, 1e-10) || !floats.EqualApprox(d1, []float64{math.Sqrt(3)}, 1e-10) {
+		fmt.Printf("Unexpected H1 pairs %v %v\n", b1, d1)
+		t.Fail()
+	}
+
+	b2, d2 := rp.BirthDeath(2)
+	if !floats.EqualApprox(b2, []float64{math.Sqrt(3)}, 1e-10) || !floats.EqualApprox(d2, []float64{2}, 1e-10) {
+		fmt.Printf("Unexpected H2 pairs %v %v\n", b2, d2)
+		t.Fail()
+	}
+
+	// The simplices are sorted by filtration value, with faces
+	// before cofaces
+	for k := 1; k < rp.NumSimplices(); k++ {
+		s0, v0 := rp.Simplex(k - 1)
+		s1, v1 := rp.Simplex(k)
+		if v1 < v0 || (v1 == v0 && len(s1) < len(s0)) {
+			fmt.Printf("Simplices %d and %d are out of order\n", k-1, k)
+			t.Fail()
+		}
+	}
+}
+
+// The H0 death times are the edge lengths of a minimum spanning tree.
+func TestRipsH0(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(63))
+
+	for jt := 0; jt < 10; jt++ {
+
+		n := 5 + rng.Intn(20)
+		x := make([]float64, 3*n)
+		for i := range x {
+			x[i] = rng.NormFloat64()
+		}
+
+		rp := NewRips(x, 3, math.Inf(1), 0)
+		_, death := rp.BirthDeath(0)
+		sort.Float64s(death)
+
+		// Prim's algorithm
+		dist := func(i, j int) float64 {
+			return floats.Distance(x[3*i:3*i+3], x[3*j:3*j+3], 2)
+		}
+		in := make([]bool, n)
+		best := make([]float64, n)
+		for i := range best {
+			best[i] = math.Inf(1)
+		}
+		best[0] = 0
+		var mst []float64
+		for k := 0; k < n; k++ {
+			u := -1
+			for i := range best {
+				if !in[i] && (u == -1 || best[i] < best[u]) {
+					u = i
+				}
+			}
+			in[u] = true
+			if k > 0 {
+				mst = append(mst, best[u])
+			}
+			for i := range best {
+				if !in[i] && dist(u, i) < best[i] {
+					best[i] = dist(u, i)
+				}
+			}
+		}
+		sort.Float64s(mst)
+
+		// The one component that never dies is given the
+		// greatest edge length.
+		if len(death) != n || !floats.EqualApprox(death[0:n-1], mst, 1e-10) {
+			fmt.Printf("Test %d: H0 deaths do not match MST\n%v\n%v\n", jt, death, mst)
+			t.Fail()
+		}
+	}
+}
+
+// The results from the distance matrix should agree with those from
+// the coordinates.
+func TestRipsDist(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(64))
+
+	n := 12
+	x := make([]float64, 2*n)
+	for i := range x {
+		x[i] = rng.Float64()
+	}
+	dist := make([]float64, n*n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			dist[i*n+j] = floats.Distance(x[2*i:2*i+2], x[2*j:2*j+2], 2)
+		}
+	}
+
+	r1 := NewRips(x, 2, 0.6, 2)
+	r2 := NewRipsDist(dist, n, 0.6, 2)
+	for dim := 0; dim <= 2; dim++ {
+		b1, d1 := r1.BirthDeath(dim)
+		b2, d2 := r2.BirthDeath(dim)
+		if !floats.EqualApprox(b1, b2, 1e-12) || !floats.EqualApprox(d1, d2, 1e-12) {
+			fmt.Printf("Dimension %d pairs do not agree\n", dim)
+			t.Fail()
+		}
+	}
+
+	// The diagrams can be used to construct landscapes
+	b, d := r1.BirthDeath(1)
+	if len(b) > 0 {
+		ls := NewLandscape(b, d)
+		ls.Eval((b[0]+d[0])/2, []int{0})
+	}
+}
@@ -0,0 +1,111 @@
+package tda
+
+import (
+	"math"
+	"sort"
+)
+
+// Silhouette is a weighted persistence silhouette (Chazal et al.,
+// 2014, Symposium on Computational Geometry), which is a weighted
+// average of the tent functions of the intervals of a persistence
+// diagram.  The tent function of the interval from b to d is
+// min(t-b, d-t) for t between b and d and zero elsewhere, and the
+// weight of the interval is |d-b|^p.  Larger values of p emphasize
+// the more persistent intervals.  The silhouette is a piecewise linear
+// function, which is represented exactly by its breakpoints.
+type Silhouette struct {
+
+	// The breakpoints, in order of increasing x coordinate
+	points []LandscapePoint
+}
+
+// NewSilhouette returns the silhouette with power p >= 0 of the
+// diagram with the given birth and death times.  Intervals of length
+// zero and intervals with infinite death times are ignored.
+func NewSilhouette(birth, death []float64, p float64) *Silhouette {
+
+	if len(birth) != len(death) {
+		panic("birth and death slices must have the same length")
+	}
+	if p < 0 {
+		panic("p must be non-negative")
+	}
+
+	// Each tent changes the slope of the silhouette at its ends
+	// and at its peak.
+	type event struct {
+		x, slope float64
+	}
+	var ev []event
+	var wt float64
+	for i := range birth {
+		lo, hi := math.Min(birth[i], death[i]), math.Max(birth[i], death[i])
+		if lo == hi || math.IsInf(hi, 0) || math.IsInf(lo, 0) {
+			continue
+		}
+		w := math.Pow(hi-lo, p)
+		wt += w
+		ev = append(ev, event{lo, w}, event{(lo + hi) / 2, -2 * w}, event{hi, w})
+	}
+
+	si := &Silhouette{}
+	if len(ev) == 0 {
+		return si
+	}
+	sort.Slice(ev, func(i, j int) bool { return ev[i].x < ev[j].x })
+
+	var y, slope float64
+	for k, e := range ev {
+		if k > 0 && e.x == ev[k-1].x {
+			slope += e.slope / wt
+			continue
+		}
+		if k > 0 {
+			y += slope * (e.x - ev[k-1].x)
+		}
+		si.points = append(si.points, LandscapePoint{e.x, y})
+		slope += e.slope / wt
+	}
+	si.points[len(si.points)-1].Y = 0
+
+	return si
+}
+
+// Breakpoints returns the breakpoints of the silhouette, in order of
+// increasing x coordinate.  The silhouette is the linear interpolation
+// of the breakpoints, and is zero outside of their range.  The
+// returned slice must not be modified.
+func (si *Silhouette) Breakpoints() []LandscapePoint {
+	return si.points
+}
+
+// Eval evaluates the silhouette at t.
+func (si *Silhouette) Eval(t float64) float64 {
+	return evalLevel(si.points, t)
+}
+
+// EvalGrid evaluates the silhouette at each point in tvals.
+func (si *Silhouette) EvalGrid(tvals []float64) []float64 {
+	y := make([]float64, len(tvals))
+	for i, t := range tvals {
+		y[i] = evalLevel(si.points, t)
+	}
+	return y
+}
+
+// Integral returns the exact integral of the silhouette.
+func (si *Silhouette) Integral() float64 {
+	var s float64
+	for k := 1; k < len(si.points); k++ {
+		p, q := si.points[k-1], si.points[k]
+		s += (q.X - p.X) * (p.Y + q.Y) / 2
+	}
+	return s
+}
+
+// Silhouette returns the silhouette with power p of the diagram
+// defined by the persistence trajectories, see BirthDeath.
+func (ps *Persistence) Silhouette(p float64) *Silhouette {
+	birth, death := ps.BirthDeath()
+	return NewSilhouette(birth, death, p)
+}
@@ -0,0 +1,76 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestSilhouette(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(171))
+
+	for jt := 0; jt < 30; jt++ {
+
+		n := 1 + rng.Intn(10)
+		birth := make([]float64, n)
+		death := make([]float64, n)
+		for i := range birth {
+			birth[i] = float64(rng.Intn(10))
+			death[i] = birth[i] + float64(rng.Intn(8))
+		}
+		p := float64(rng.Intn(3))
+		si := NewSilhouette(birth, death, p)
+
+		// Compare to the weighted average of the tents
+		var wt, area float64
+		for i := range birth {
+			if l := death[i] - birth[i]; l > 0 {
+				w := math.Pow(l, p)
+				wt += w
+				area += w * l * l / 4
+			}
+		}
+		var tvals []float64
+		for k := 0; k <= 400; k++ {
+			tvals = append(tvals, -1+float64(k)/20)
+		}
+		y := si.EvalGrid(tvals)
+		for k, tv := range tvals {
+			var e float64
+			for i := range birth {
+				if h := math.Min(tv-birth[i], death[i]-tv); h > 0 {
+					e += math.Pow(death[i]-birth[i], p) * h / wt
+				}
+			}
+			if math.Abs(y[k]-e) > 1e-10 {
+				fmt.Printf("Test %d: silhouette at %v is %v, expected %v\n", jt, tv, y[k], e)
+				t.Fail()
+				break
+			}
+		}
+
+		if wt > 0 {
+			area /= wt
+		}
+		if math.Abs(si.Integral()-area) > 1e-10 {
+			fmt.Printf("Test %d: integral is %v, expected %v\n", jt, si.Integral(), area)
+			t.Fail()
+		}
+	}
+
+	// The silhouette of the persistence trajectories
+	img := []int{
+		0, 0, 0, 0, 0, 0, 0,
+		0, 9, 5, 3, 6, 8, 0,
+		0, 0, 0, 0, 0, 0, 0,
+	}
+	ps := NewPersistence(img, 3, 10)
+	birth, death := ps.BirthDeath()
+	si := ps.Silhouette(1)
+	if fmt.Sprint(si.Breakpoints()) != fmt.Sprint(NewSilhouette(birth, death, 1).Breakpoints()) {
+		fmt.Printf("Unexpected breakpoints %v\n", si.Breakpoints())
+		t.Fail()
+	}
+}
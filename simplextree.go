@@ -0,0 +1,444 @@
+package tda
+
+import (
+	"math"
+	"sort"
+)
+
+// stNode is a node of a simplex tree.  Each node represents the
+// simplex whose vertices are the labels on the path from the root to
+// the node.
+type stNode struct {
+
+	// The last vertex of the simplex
+	vertex int
+
+	// The filtration value of the simplex
+	value float64
+
+	// The number of vertices in the simplex
+	depth int
+
+	parent *stNode
+
+	// The child nodes, sorted by vertex
+	children []*stNode
+}
+
+// child returns the child of the node with the given vertex, or nil
+// if there is no such child.
+func (nd *stNode) child(v int) *stNode {
+	i := sort.Search(len(nd.children), func(i int) bool {
+		return nd.children[i].vertex >= v
+	})
+	if i < len(nd.children) && nd.children[i].vertex == v {
+		return nd.children[i]
+	}
+	return nil
+}
+
+// addChild returns the child of the node with the given vertex,
+// creating it with the given value if it does not exist.  The second
+// return value is true if the child was created.
+func (nd *stNode) addChild(v int, value float64) (*stNode, bool) {
+	i := sort.Search(len(nd.children), func(i int) bool {
+		return nd.children[i].vertex >= v
+	})
+	if i < len(nd.children) && nd.children[i].vertex == v {
+		return nd.children[i], false
+	}
+	c := &stNode{
+		vertex: v,
+		value:  value,
+		depth:  nd.depth + 1,
+		parent: nd,
+	}
+	nd.children = append(nd.children, nil)
+	copy(nd.children[i+1:], nd.children[i:])
+	nd.children[i] = c
+	return c, true
+}
+
+// simplex returns the vertices of the simplex represented by the node.
+func (nd *stNode) simplex() []int {
+	s := make([]int, nd.depth)
+	for u := nd; u.depth > 0; u = u.parent {
+		s[u.depth-1] = u.vertex
+	}
+	return s
+}
+
+// SimplexTree is a simplicial complex with a filtration value for
+// each simplex, represented as a simplex tree (Boissonnat and Maria,
+// 2014, Algorithmica 70:3).  Each simplex is a set of vertices, which
+// are non-negative integers.  The simplices are stored in a trie in
+// which the path from the root to the node representing a simplex
+// follows its vertices in increasing order.  The nodes with a given
+// vertex are also linked, so that the cofaces of a simplex can be
+// found without searching the whole tree.
+//
+// The filtration values are expected to be monotone, so that each
+// simplex has a value no less than the values of its faces.  Insert
+// and Expand maintain this property, and MakeNonDecreasing restores
+// it after values are assigned with SetValue.
+type SimplexTree struct {
+
+	// The root node represents the empty simplex
+	root stNode
+
+	// The nodes for each vertex
+	lists map[int][]*stNode
+
+	// The number of simplices
+	count int
+}
+
+// NewSimplexTree returns an empty simplex tree.
+func NewSimplexTree() *SimplexTree {
+	return &SimplexTree{
+		lists: make(map[int][]*stNode),
+	}
+}
+
+// sortedSimplex returns a sorted copy of the vertices in s, and panics
+// if a vertex is negative or repeated.
+func sortedSimplex(s []int) []int {
+
+	t := make([]int, len(s))
+	copy(t, s)
+	sort.Ints(t)
+
+	for i, v := range t {
+		if v < 0 {
+			panic("vertices must be non-negative")
+		}
+		if i > 0 && v == t[i-1] {
+			panic("repeated vertex in simplex")
+		}
+	}
+
+	return t
+}
+
+// find returns the node of the sorted simplex s, or nil if it is not
+// in the complex.
+func (st *SimplexTree) find(s []int) *stNode {
+	nd := &st.root
+	for _, v := range s {
+		if nd = nd.child(v); nd == nil {
+			return nil
+		}
+	}
+	return nd
+}
+
+// insertSorted inserts the sorted simplex s and all of its faces.
+func (st *SimplexTree) insertSorted(s []int, value float64) bool {
+
+	// Insert the faces by inserting, for each subset of the
+	// vertices, the path that follows it.  A subset is visited
+	// after all of its own subsets.
+	var created bool
+	var rec func(nd *stNode, i int)
+	rec = func(nd *stNode, i int) {
+		for j := i; j < len(s); j++ {
+			c, isNew := nd.addChild(s[j], value)
+			if isNew {
+				st.lists[s[j]] = append(st.lists[s[j]], c)
+				st.count++
+				if c.depth == len(s) {
+					created = true
+				}
+			} else if c.value > value {
+				c.value = value
+			}
+			rec(c, j+1)
+		}
+	}
+	rec(&st.root, 0)
+
+	return created
+}
+
+// Insert adds the simplex with the given vertices to the complex,
+// along with all of its faces.  Faces that are not already in the
+// complex are given the provided filtration value, and faces that are
+// already present with greater values have their values reduced to
+// the provided value.  The return value is true if the simplex was
+// not already in the complex.
+func (st *SimplexTree) Insert(s []int, value float64) bool {
+	if len(s) == 0 {
+		return false
+	}
+	return st.insertSorted(sortedSimplex(s), value)
+}
+
+// Contains returns true if the simplex with the given vertices is in
+// the complex.
+func (st *SimplexTree) Contains(s []int) bool {
+	return len(s) > 0 && st.find(sortedSimplex(s)) != nil
+}
+
+// Value returns the filtration value of the simplex with the given
+// vertices.  The second return value is false if the simplex is not in
+// the complex.
+func (st *SimplexTree) Value(s []int) (float64, bool) {
+	if len(s) == 0 {
+		return 0, false
+	}
+	nd := st.find(sortedSimplex(s))
+	if nd == nil {
+		return 0, false
+	}
+	return nd.value, true
+}
+
+// SetValue assigns a filtration value to the simplex with the given
+// vertices, and returns false if the simplex is not in the complex.
+// The values of the faces and cofaces are not changed, see
+// MakeNonDecreasing.
+func (st *SimplexTree) SetValue(s []int, value float64) bool {
+	if len(s) == 0 {
+		return false
+	}
+	nd := st.find(sortedSimplex(s))
+	if nd == nil {
+		return false
+	}
+	nd.value = value
+	return true
+}
+
+// MakeNonDecreasing increases the filtration value of each simplex as
+// needed so that it is no less than the values of its faces.  The
+// return value is true if any values were changed.
+func (st *SimplexTree) MakeNonDecreasing() bool {
+
+	changed := false
+	for _, nd := range st.nodesByDim() {
+		if nd.depth < 2 {
+			continue
+		}
+		s := nd.simplex()
+		for _, f := range facets(s) {
+			if v := st.find(f).value; v > nd.value {
+				nd.value = v
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+// facets returns the faces of the sorted simplex s that have one
+// fewer vertex.
+func facets(s []int) [][]int {
+	if len(s) < 2 {
+		return nil
+	}
+	var f [][]int
+	for i := range s {
+		g := make([]int, 0, len(s)-1)
+		g = append(g, s[0:i]...)
+		g = append(g, s[i+1:]...)
+		f = append(f, g)
+	}
+	return f
+}
+
+// nodesByDim returns all nodes, ordered by increasing dimension.
+func (st *SimplexTree) nodesByDim() []*stNode {
+
+	var nodes []*stNode
+	level := st.root.children
+	for len(level) > 0 {
+		nodes = append(nodes, level...)
+		var next []*stNode
+		for _, nd := range level {
+			next = append(next, nd.children...)
+		}
+		level = next
+	}
+
+	return nodes
+}
+
+// NumSimplices returns the number of simplices in the complex.
+func (st *SimplexTree) NumSimplices() int {
+	return st.count
+}
+
+// NumVertices returns the number of vertices in the complex.
+func (st *SimplexTree) NumVertices() int {
+	return len(st.root.children)
+}
+
+// Dim returns the dimension of the complex, which is the greatest
+// dimension of its simplices, or -1 if the complex is empty.
+func (st *SimplexTree) Dim() int {
+	d := -1
+	var rec func(nd *stNode)
+	rec = func(nd *stNode) {
+		if nd.depth-1 > d {
+			d = nd.depth - 1
+		}
+		for _, c := range nd.children {
+			rec(c)
+		}
+	}
+	rec(&st.root)
+	return d
+}
+
+// Simplices returns the simplices of the given dimension, with the
+// vertices of each simplex in increasing order.  The simplices are in
+// lexicographic order.
+func (st *SimplexTree) Simplices(dim int) [][]int {
+
+	var r [][]int
+	var rec func(nd *stNode)
+	rec = func(nd *stNode) {
+		if nd.depth == dim+1 {
+			r = append(r, nd.simplex())
+			return
+		}
+		for _, c := range nd.children {
+			rec(c)
+		}
+	}
+	rec(&st.root)
+
+	return r
+}
+
+// Faces returns the faces of the simplex with the given vertices that
+// have one fewer vertex (the facets that make up its boundary).  The
+// simplex must be in the complex.
+func (st *SimplexTree) Faces(s []int) [][]int {
+	s = sortedSimplex(s)
+	if st.find(s) == nil {
+		panic("simplex is not in the complex")
+	}
+	return facets(s)
+}
+
+// Cofaces returns the cofaces of the simplex with the given vertices
+// that have codim more vertices.  If codim is zero, all proper
+// cofaces are returned.  The vertices of each coface are in
+// increasing order.
+func (st *SimplexTree) Cofaces(s []int, codim int) [][]int {
+
+	s = sortedSimplex(s)
+	if len(s) == 0 {
+		return nil
+	}
+
+	// Each coface follows a path through a node with the last
+	// vertex of s, whose simplex contains s.
+	var r [][]int
+	last := s[len(s)-1]
+	var rec func(nd *stNode)
+	rec = func(nd *stNode) {
+		if k := nd.depth - len(s); k > 0 && (codim == 0 || k == codim) {
+			r = append(r, nd.simplex())
+		}
+		if codim == 0 || nd.depth-len(s) < codim {
+			for _, c := range nd.children {
+				rec(c)
+			}
+		}
+	}
+	for _, nd := range st.lists[last] {
+		if nd.depth < len(s) {
+			continue
+		}
+
+		// Check that the path contains s
+		j := len(s) - 1
+		for u := nd; u.depth > 0 && j >= 0; u = u.parent {
+			if u.vertex == s[j] {
+				j--
+			} else if u.vertex < s[j] {
+				break
+			}
+		}
+		if j < 0 {
+			rec(nd)
+		}
+	}
+
+	return r
+}
+
+// Expand adds to the complex every simplex of dimension at most
+// maxDim whose edges are all in the complex (the clique or flag
+// complex of the one-dimensional skeleton).  Each added simplex is
+// given the greatest filtration value of its faces.
+func (st *SimplexTree) Expand(maxDim int) {
+
+	// The simplices of each dimension are obtained from the
+	// simplices of the previous dimension.  A simplex can be
+	// extended by a vertex u greater than its last vertex v if the
+	// simplex obtained by replacing v with u is present (a sibling
+	// node) and the edge joining v and u is present.
+	level := st.Simplices(1)
+	for dim := 2; dim <= maxDim && len(level) > 0; dim++ {
+		var next [][]int
+		for _, s := range level {
+			nd := st.find(s)
+			v := s[len(s)-1]
+			ev := st.root.child(v)
+			for _, sib := range nd.parent.children {
+				u := sib.vertex
+				if u <= v || ev.child(u) == nil {
+					continue
+				}
+				t := append(append([]int(nil), s...), u)
+				if nd.child(u) == nil {
+					w := math.Inf(-1)
+					for _, f := range facets(t) {
+						w = math.Max(w, st.find(f).value)
+					}
+					c, _ := nd.addChild(u, w)
+					st.lists[u] = append(st.lists[u], c)
+					st.count++
+				}
+				next = append(next, t)
+			}
+		}
+		level = next
+	}
+}
+
+// Filtration returns all simplices in filtration order, along with
+// their filtration values.  The simplices are ordered by value, with
+// ties broken by placing lower dimensional simplices first, and then
+// lexicographically, so that each simplex follows its faces if the
+// values are monotone.
+func (st *SimplexTree) Filtration() ([][]int, []float64) {
+
+	nodes := st.nodesByDim()
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].value != nodes[j].value {
+			return nodes[i].value < nodes[j].value
+		}
+		return nodes[i].depth < nodes[j].depth
+	})
+
+	simplices := make([][]int, len(nodes))
+	values := make([]float64, len(nodes))
+	for i, nd := range nodes {
+		simplices[i] = nd.simplex()
+		values[i] = nd.value
+	}
+
+	return simplices, values
+}
+
+// BoundaryMatrix returns the boundary matrix of the filtration given
+// by Filtration.  The filtration values must be monotone.
+func (st *SimplexTree) BoundaryMatrix() *BoundaryMatrix {
+	simplices, values := st.Filtration()
+	return NewSimplicialBoundaryMatrix(simplices, values)
+}
@@ -0,0 +1,171 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/floats"
+)
+
+func TestSimplexTreeInsert(t *testing.T) {
+
+	st := NewSimplexTree()
+
+	// A triangle and all of its faces
+	if !st.Insert([]int{2, 0, 1}, 3) {
+		fmt.Printf("Triangle was not inserted\n")
+		t.Fail()
+	}
+	if st.NumSimplices() != 7 || st.NumVertices() != 3 || st.Dim() != 2 {
+		fmt.Printf("Unexpected complex: %d simplices, %d vertices, dimension %d\n",
+			st.NumSimplices(), st.NumVertices(), st.Dim())
+		t.Fail()
+	}
+
+	// An existing edge, with a lower value that is passed to its
+	// vertices
+	if st.Insert([]int{1, 2}, 1) {
+		fmt.Printf("Edge was inserted twice\n")
+		t.Fail()
+	}
+	for _, s := range [][]int{{1}, {2}, {1, 2}} {
+		if v, ok := st.Value(s); !ok || v != 1 {
+			fmt.Printf("Simplex %v has value %v, expected 1\n", s, v)
+			t.Fail()
+		}
+	}
+	if v, _ := st.Value([]int{0, 1, 2}); v != 3 {
+		fmt.Printf("Triangle has value %v, expected 3\n", v)
+		t.Fail()
+	}
+
+	// A dangling edge
+	st.Insert([]int{3, 1}, 2)
+	if !st.Contains([]int{1, 3}) || st.Contains([]int{0, 3}) || st.NumSimplices() != 9 {
+		fmt.Printf("Dangling edge was not inserted correctly\n")
+		t.Fail()
+	}
+
+	if f := st.Faces([]int{0, 1, 2}); !reflect.DeepEqual(f, [][]int{{1, 2}, {0, 2}, {0, 1}}) {
+		fmt.Printf("Unexpected faces %v\n", f)
+		t.Fail()
+	}
+
+	c := st.Cofaces([]int{1}, 1)
+	if !reflect.DeepEqual(c, [][]int{{0, 1}, {1, 2}, {1, 3}}) {
+		fmt.Printf("Unexpected cofaces %v\n", c)
+		t.Fail()
+	}
+	c = st.Cofaces([]int{1}, 0)
+	if !reflect.DeepEqual(c, [][]int{{0, 1}, {0, 1, 2}, {1, 2}, {1, 3}}) {
+		fmt.Printf("Unexpected cofaces %v\n", c)
+		t.Fail()
+	}
+	c = st.Cofaces([]int{0, 2}, 0)
+	if !reflect.DeepEqual(c, [][]int{{0, 1, 2}}) {
+		fmt.Printf("Unexpected cofaces %v\n", c)
+		t.Fail()
+	}
+
+	// Assign a value that violates monotonicity, then fix it
+	st.SetValue([]int{1, 3}, 0)
+	if !st.MakeNonDecreasing() {
+		fmt.Printf("Values were not changed\n")
+		t.Fail()
+	}
+	if v, _ := st.Value([]int{1, 3}); v != 2 {
+		fmt.Printf("Edge has value %v, expected 2\n", v)
+		t.Fail()
+	}
+
+	simp, vals := st.Filtration()
+	esimp := [][]int{{1}, {2}, {1, 2}, {3}, {1, 3}, {0}, {0, 1}, {0, 2}, {0, 1, 2}}
+	evals := []float64{1, 1, 1, 2, 2, 3, 3, 3, 3}
+	if !reflect.DeepEqual(simp, esimp) || !floats.Equal(vals, evals) {
+		fmt.Printf("Unexpected filtration\n%v\n%v\n", simp, vals)
+		t.Fail()
+	}
+}
+
+func TestSimplexTreeExpand(t *testing.T) {
+
+	// The complete graph on five vertices expands to a
+	// 4-simplex.
+	st := NewSimplexTree()
+	for i := 0; i < 5; i++ {
+		for j := i + 1; j < 5; j++ {
+			st.Insert([]int{i, j}, float64(i+j))
+		}
+	}
+
+	st.Expand(2)
+	if st.NumSimplices() != 5+10+10 || st.Dim() != 2 {
+		fmt.Printf("Found %d simplices, expected 25\n", st.NumSimplices())
+		t.Fail()
+	}
+
+	st.Expand(10)
+	if st.NumSimplices() != 31 || st.Dim() != 4 {
+		fmt.Printf("Found %d simplices, expected 31\n", st.NumSimplices())
+		t.Fail()
+	}
+	if v, _ := st.Value([]int{0, 1, 2}); v != 3 {
+		fmt.Printf("Triangle has value %v, expected 3\n", v)
+		t.Fail()
+	}
+	if v, _ := st.Value([]int{0, 1, 2, 3, 4}); v != 7 {
+		fmt.Printf("Simplex has value %v, expected 7\n", v)
+		t.Fail()
+	}
+	if n := len(st.Simplices(3)); n != 5 {
+		fmt.Printf("Found %d tetrahedra, expected 5\n", n)
+		t.Fail()
+	}
+}
+
+// Expanding the neighborhood graph of a point cloud gives the Rips
+// filtration.
+func TestSimplexTreeRips(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(81))
+
+	n := 15
+	x := make([]float64, 2*n)
+	for i := range x {
+		x[i] = rng.Float64()
+	}
+
+	st := NewSimplexTree()
+	for i := 0; i < n; i++ {
+		st.Insert([]int{i}, 0)
+		for j := 0; j < i; j++ {
+			r := floats.Distance(x[2*i:2*i+2], x[2*j:2*j+2], 2)
+			if r <= 0.5 {
+				st.Insert([]int{j, i}, r)
+			}
+		}
+	}
+	st.Expand(3)
+
+	rp := NewRips(x, 2, 0.5, 2)
+	if st.NumSimplices() != rp.NumSimplices() {
+		fmt.Printf("Found %d simplices, expected %d\n", st.NumSimplices(), rp.NumSimplices())
+		t.FailNow()
+	}
+
+	simp, vals := st.Filtration()
+	for k := range simp {
+		if v, ok := st.Value(simp[k]); !ok || v != vals[k] {
+			fmt.Printf("Simplex %v has the wrong value\n", simp[k])
+			t.Fail()
+		}
+		_, v := rp.Simplex(k)
+		if math.Abs(v-vals[k]) > 1e-12 {
+			fmt.Printf("Position %d has value %v, expected %v\n", k, vals[k], v)
+			t.Fail()
+		}
+	}
+}
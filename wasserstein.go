@@ -0,0 +1,226 @@
+package tda
+
+import (
+	"math"
+)
+
+// GroundMetric specifies the distance between points of persistence
+// diagrams used to define a Wasserstein distance.
+type GroundMetric int
+
+const (
+	// MaxNorm is the distance in the maximum (L-infinity) norm.
+	MaxNorm GroundMetric = iota
+
+	// EuclideanNorm is the Euclidean (L2) distance.
+	EuclideanNorm
+)
+
+// WassersteinOptions contains optional settings for WassersteinOpts.
+// The zero value gives the settings used by Wasserstein.
+type WassersteinOptions struct {
+
+	// The distance between points of the diagrams, the default is
+	// MaxNorm.
+	Metric GroundMetric
+}
+
+// dist returns the distance between two points of persistence
+// diagrams.
+func (gm GroundMetric) dist(b1, d1, b2, d2 float64) float64 {
+	switch gm {
+	case MaxNorm:
+		return maxDist(b1, d1, b2, d2)
+	case EuclideanNorm:
+		return math.Hypot(b1-b2, d1-d2)
+	default:
+		panic("unknown ground metric")
+	}
+}
+
+// diagonal returns the distance from a point of a persistence diagram
+// to the nearest point on the diagonal.
+func (gm GroundMetric) diagonal(b, d float64) float64 {
+	switch gm {
+	case MaxNorm:
+		return diagonalDist(b, d)
+	case EuclideanNorm:
+		return math.Abs(d-b) / math.Sqrt2
+	default:
+		panic("unknown ground metric")
+	}
+}
+
+// Wasserstein returns the q-Wasserstein distance between two
+// persistence diagrams, given as slices of birth and death times (e.g.
+// as returned by BirthDeath), using the maximum norm as the distance
+// between points.  An optimal matching is also returned.  See
+// WassersteinOpts for more details.
+func Wasserstein(birth1, death1, birth2, death2 []float64, q float64) (float64, []DiagramMatch) {
+	return WassersteinOpts(birth1, death1, birth2, death2, q, WassersteinOptions{})
+}
+
+// WassersteinOpts returns the q-Wasserstein distance between two
+// persistence diagrams using the given options, and an optimal
+// matching.  The distance is the least value of the q-th root of the
+// sum of the q-th powers of the distances between matched points, over
+// all matchings in which points can also be matched to the nearest
+// points on the diagonal.  The exponent q must be at least 1, see
+// Bottleneck for the limiting case.  Points with infinite death times
+// can only be matched to each other, and are matched in order of
+// birth time.  If the two diagrams have different numbers of such
+// points, the distance is +Inf and the matching is nil.
+//
+// The optimal matching is found with the Hungarian algorithm
+// (Kuhn-Munkres) on the square cost matrix in which each diagram is
+// augmented with the diagonal projections of the points of the other
+// diagram, which takes time proportional to the cube of the total
+// number of points.
+//
+// The matching contains every point of both diagrams exactly once, as
+// a pair of points or as a point matched to the diagonal.  The Cost
+// field of each match is the distance between the matched points.
+func WassersteinOpts(birth1, death1, birth2, death2 []float64, q float64, opts WassersteinOptions) (float64, []DiagramMatch) {
+
+	if q < 1 || math.IsInf(q, 1) || math.IsNaN(q) {
+		panic("q must be finite and at least 1")
+	}
+	gm := opts.Metric
+
+	fin1, pinf1, ninf1 := splitDiagram(birth1, death1)
+	fin2, pinf2, ninf2 := splitDiagram(birth2, death2)
+
+	ep, ok1 := matchEssential(birth1, birth2, pinf1, pinf2)
+	en, ok2 := matchEssential(birth1, birth2, ninf1, ninf2)
+	if !ok1 || !ok2 {
+		return math.Inf(1), nil
+	}
+
+	// The left vertices are the points of the first diagram,
+	// followed by the projections of the points of the second
+	// diagram.  The right vertices are the points of the second
+	// diagram, followed by the projections of the points of the
+	// first diagram.  A point can only be matched to its own
+	// projection, and two projections can be matched at no cost.
+	n1, n2 := len(fin1), len(fin2)
+	n := n1 + n2
+	cost := make([][]float64, n)
+	for k := range cost {
+		cost[k] = make([]float64, n)
+	}
+	for k, i := range fin1 {
+		for l, j := range fin2 {
+			cost[k][l] = math.Pow(gm.dist(birth1[i], death1[i], birth2[j], death2[j]), q)
+		}
+		for l := range fin1 {
+			cost[k][n2+l] = math.Inf(1)
+		}
+		cost[k][n2+k] = math.Pow(gm.diagonal(birth1[i], death1[i]), q)
+	}
+	for k, j := range fin2 {
+		for l := range fin2 {
+			cost[n1+k][l] = math.Inf(1)
+		}
+		cost[n1+k][k] = math.Pow(gm.diagonal(birth2[j], death2[j]), q)
+	}
+
+	assign := hungarian(cost)
+
+	var m []DiagramMatch
+	for k, i := range fin1 {
+		if l := assign[k]; l < n2 {
+			j := fin2[l]
+			m = append(m, DiagramMatch{First: i, Second: j, Cost: gm.dist(birth1[i], death1[i], birth2[j], death2[j])})
+		} else {
+			m = append(m, DiagramMatch{First: i, Second: -1, Cost: gm.diagonal(birth1[i], death1[i])})
+		}
+	}
+	for k, j := range fin2 {
+		if assign[n1+k] == k {
+			m = append(m, DiagramMatch{First: -1, Second: j, Cost: gm.diagonal(birth2[j], death2[j])})
+		}
+	}
+	m = append(m, ep...)
+	m = append(m, en...)
+
+	var s float64
+	for _, e := range m {
+		s += math.Pow(e.Cost, q)
+	}
+
+	return math.Pow(s, 1/q), m
+}
+
+// hungarian solves the assignment problem for a square cost matrix,
+// returning the column assigned to each row in an assignment of least
+// total cost.  Entries of +Inf mark forbidden assignments, but a
+// finite assignment must exist.  This is the shortest augmenting path
+// form of the Hungarian algorithm with row and column potentials.
+func hungarian(cost [][]float64) []int {
+
+	n := len(cost)
+
+	// The arrays are indexed from 1, with position 0 of p holding
+	// the row being added.
+	u := make([]float64, n+1)
+	v := make([]float64, n+1)
+	p := make([]int, n+1)
+	way := make([]int, n+1)
+	minv := make([]float64, n+1)
+	used := make([]bool, n+1)
+
+	for i := 1; i <= n; i++ {
+		p[0] = i
+		j0 := 0
+		for j := range minv {
+			minv[j] = math.Inf(1)
+			used[j] = false
+		}
+		for {
+			used[j0] = true
+			i0 := p[j0]
+			delta := math.Inf(1)
+			j1 := -1
+			for j := 1; j <= n; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost[i0-1][j-1] - u[i0] - v[j]; cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta || j1 == -1 {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			if math.IsInf(delta, 1) {
+				panic("no finite assignment exists")
+			}
+			for j := 0; j <= n; j++ {
+				if used[j] {
+					u[p[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+			if p[j0] == 0 {
+				break
+			}
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			p[j0] = p[j1]
+			j0 = j1
+		}
+	}
+
+	assign := make([]int, n)
+	for j := 1; j <= n; j++ {
+		assign[p[j]-1] = j - 1
+	}
+
+	return assign
+}
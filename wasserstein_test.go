@@ -0,0 +1,109 @@
+package tda
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestWasserstein(t *testing.T) {
+
+	// The first point is matched to the second point, and the
+	// remaining point is matched to the diagonal.
+	b1, d1 := []float64{0, 3}, []float64{10, 4}
+	b2, d2 := []float64{1}, []float64{12}
+	dist, m := Wasserstein(b1, d1, b2, d2, 1)
+	if dist != 2.5 {
+		fmt.Printf("Found distance %v, expected 2.5\n", dist)
+		t.Fail()
+	}
+	expected := []DiagramMatch{{0, 0, 2}, {1, -1, 0.5}}
+	if fmt.Sprint(m) != fmt.Sprint(expected) {
+		fmt.Printf("Unexpected matching %v\n", m)
+		t.Fail()
+	}
+
+	dist, _ = WassersteinOpts(b1, d1, b2, d2, 2, WassersteinOptions{Metric: EuclideanNorm})
+	if math.Abs(dist-math.Sqrt(5+0.5)) > 1e-12 {
+		fmt.Printf("Found distance %v, expected %v\n", dist, math.Sqrt(5.5))
+		t.Fail()
+	}
+
+	// Points with infinite death times
+	b1, d1 = []float64{0, 3, 1}, []float64{math.Inf(1), 4, math.Inf(1)}
+	b2, d2 = []float64{2, 5}, []float64{math.Inf(1), math.Inf(1)}
+	if dist, m = Wasserstein(b1, d1, b2, d2, 1); dist != 6.5 || checkMatching(m, 3, 2) != "" {
+		fmt.Printf("Found distance %v, expected 6.5\n", dist)
+		t.Fail()
+	}
+	if dist, m = Wasserstein(b1, d1, b2[0:1], d2[0:1], 1); !math.IsInf(dist, 1) || m != nil {
+		fmt.Printf("Found distance %v, expected +Inf\n", dist)
+		t.Fail()
+	}
+}
+
+// The Wasserstein distance agrees with a search over all matchings of
+// small diagrams.
+func TestWassersteinBrute(t *testing.T) {
+
+	rng := rand.New(rand.NewSource(121))
+
+	for jt := 0; jt < 100; jt++ {
+
+		b1, d1 := randomDiagram(rng, rng.Intn(4))
+		b2, d2 := randomDiagram(rng, rng.Intn(4))
+		q := float64(1 + rng.Intn(3))
+		gm := GroundMetric(jt % 2)
+
+		c := augmentedCosts(b1, d1, b2, d2)
+		n1, n2 := len(b1), len(b2)
+		for i := range c {
+			for j := range c[i] {
+				switch {
+				case i < n1 && j < n2:
+					c[i][j] = gm.dist(b1[i], d1[i], b2[j], d2[j])
+				case i < n1 && j-n2 == i:
+					c[i][j] = gm.diagonal(b1[i], d1[i])
+				case i >= n1 && j == i-n1:
+					c[i][j] = gm.diagonal(b2[j], d2[j])
+				}
+			}
+		}
+		best := math.Inf(1)
+		permutations(len(c), func(p []int) {
+			var v float64
+			for i, j := range p {
+				v += math.Pow(c[i][j], q)
+			}
+			best = math.Min(best, v)
+		})
+		if len(c) == 0 {
+			best = 0
+		}
+		best = math.Pow(best, 1/q)
+
+		dist, m := WassersteinOpts(b1, d1, b2, d2, q, WassersteinOptions{Metric: gm})
+		if math.Abs(dist-best) > 1e-9 {
+			fmt.Printf("Test %d: found distance %v, expected %v\n", jt, dist, best)
+			t.Fail()
+		}
+		if msg := checkMatching(m, n1, n2); msg != "" {
+			fmt.Printf("Test %d: %s\n", jt, msg)
+			t.Fail()
+		}
+
+		if d, _ := WassersteinOpts(b2, d2, b1, d1, q, WassersteinOptions{Metric: gm}); math.Abs(d-dist) > 1e-9 {
+			fmt.Printf("Test %d: distance is not symmetric\n", jt)
+			t.Fail()
+		}
+		if d, _ := Wasserstein(b1, d1, b1, d1, q); d != 0 {
+			fmt.Printf("Test %d: diagram has distance %v to itself\n", jt, d)
+			t.Fail()
+		}
+		if b, _ := Bottleneck(b1, d1, b2, d2); gm == MaxNorm && b > dist+1e-9 {
+			fmt.Printf("Test %d: Wasserstein distance is less than the bottleneck distance\n", jt)
+			t.Fail()
+		}
+	}
+}